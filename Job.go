@@ -1 +1,153 @@
 package lash
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type (
+	// Job an external command to run
+	Job struct {
+		scope *Scope
+		line  string
+		Cmd   *exec.Cmd
+	}
+)
+
+// Exec prepares a command line, each argument supports EnvStr except for single quoted
+// or escaped text e.g. awk '{print $2}' or \$HOME, as in a shell.
+// The command is not started until Run, Output or Lines is called, it is killed if the scope is cancelled
+func (s *Scope) Exec(command string, args ...interface{}) *Job {
+	j := &Job{scope: s, line: command}
+	split, err := splitArgParts(command)
+	if err == nil && len(split) == 0 {
+		err = xerrors.New("empty command")
+	}
+	if err != nil {
+		s.setErr("Job", "Exec", err)
+		return j
+	}
+	parts := make([]string, len(split))
+	for i, arg := range split {
+		for _, p := range arg {
+			if p.literal {
+				parts[i] += p.text
+			} else {
+				parts[i] += s.EnvStr(p.text, args...)
+			}
+		}
+	}
+	j.line = strings.Join(parts, " ")
+	j.Cmd = exec.CommandContext(s.Context(), parts[0], parts[1:]...)
+	return j
+}
+
+// Dir to run the command in, defaults to the current directory
+func (j *Job) Dir(path string, args ...interface{}) *Job {
+	if j.Cmd != nil {
+		j.Cmd.Dir = j.scope.EnvStr(path, args...)
+	}
+	return j
+}
+
+// Env sets a variable for the command only, the rest of the environment is inherited
+func (j *Job) Env(key, value string, args ...interface{}) *Job {
+	if j.Cmd != nil {
+		if j.Cmd.Env == nil {
+			j.Cmd.Env = os.Environ()
+		}
+		j.Cmd.Env = append(j.Cmd.Env, key+"="+j.scope.EnvStr(value, args...))
+	}
+	return j
+}
+
+// Stdin for the command
+func (j *Job) Stdin(r io.Reader) *Job {
+	if j.Cmd != nil {
+		j.Cmd.Stdin = r
+	}
+	return j
+}
+
+// Run the command, output goes to the scope outputs, returns the exit code
+// or -1 if the command could not be run. In a dry run the command is not run and 0 is returned
+func (j *Job) Run() int {
+	if j.Cmd == nil {
+		return -1
+	}
+	if j.dryRun() {
+		return 0
+	}
+	j.Cmd.Stdout = j.scope.stdout
+	j.Cmd.Stderr = j.scope.stderr
+	j.fail("Run", j.Cmd.Run())
+	return j.exitCode()
+}
+
+// Output of the command as a string, trailing new lines are removed. Empty in a dry run
+func (j *Job) Output() string {
+	if j.Cmd == nil || j.dryRun() {
+		return ""
+	}
+	var buf bytes.Buffer
+	j.Cmd.Stdout = &buf
+	j.Cmd.Stderr = j.scope.stderr
+	j.fail("Output", j.Cmd.Run())
+	return strings.TrimRight(buf.String(), "\r\n")
+}
+
+// Lines of output via a channel one line at a time, see File.ReadLines. No lines in a dry run
+func (j *Job) Lines() chan string {
+	ch := make(chan string)
+	if j.Cmd == nil || j.dryRun() {
+		close(ch)
+		return ch
+	}
+	j.Cmd.Stderr = j.scope.stderr
+	out, err := j.Cmd.StdoutPipe()
+	if err == nil {
+		err = j.Cmd.Start()
+	}
+	if err != nil {
+		j.fail("Lines", err)
+		close(ch)
+		return ch
+	}
+
+	go func() {
+		defer close(ch)
+		scanner := bufio.NewScanner(out)
+		scanner.Split(bufio.ScanLines)
+		for scanner.Scan() {
+			ch <- scanner.Text()
+		}
+		j.fail("Lines", j.Cmd.Wait())
+	}()
+
+	return ch
+}
+
+// dryRun reports the command line, true if it must not be run
+func (j *Job) dryRun() bool {
+	return j.scope.dryRunf("run '%s'", j.line)
+}
+
+func (j *Job) exitCode() int {
+	if j.Cmd.ProcessState == nil {
+		return -1
+	}
+	return j.Cmd.ProcessState.ExitCode()
+}
+
+func (j *Job) fail(action string, err error) {
+	if err == nil {
+		return
+	}
+	j.scope.SetErr(&ScopeErr{Type: "Job", Action: action, Err: xerrors.Errorf("'%s': %w", j.line, err)})
+}
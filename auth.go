@@ -0,0 +1,111 @@
+package lash
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type oauthToken struct {
+	value   string
+	expires time.Time
+}
+
+// tokens are refreshed this long (or half their lifetime if that is shorter) before they expire
+const tokenExpirySkew = 30 * time.Second
+
+// dryRunToken is sent instead of fetching a token in a dry run
+const dryRunToken = "dry-run"
+
+// AuthBasic sets the Authorization header, both values support EnvStr with the same args
+func (cmd *HTTPRequest) AuthBasic(username, password string, args ...interface{}) *HTTPRequest {
+	cmd.Req.SetBasicAuth(cmd.scope.EnvStr(username, args...), cmd.scope.EnvStr(password, args...))
+	return cmd
+}
+
+// AuthBearer sets the Authorization header, the token supports EnvStr
+func (cmd *HTTPRequest) AuthBearer(token string, args ...interface{}) *HTTPRequest {
+	return cmd.Header("Authorization", "Bearer "+cmd.scope.EnvStr(token, args...))
+}
+
+// AuthClientCredentials gets a token using the OAuth2 client credentials flow and sets it as a Bearer token.
+// The token is cached on the scope (shared with child scopes) and fetched again when it expires.
+// All values support EnvStr. In a dry run no token is fetched and the Bearer token is a placeholder
+func (cmd *HTTPRequest) AuthClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) *HTTPRequest {
+	if cmd.scope.dryRunf("fetch token from '%s'", cmd.scope.EnvStr(tokenURL)) {
+		cmd.Req.Header.Set("Authorization", "Bearer "+dryRunToken)
+		return cmd
+	}
+	tokenURL = cmd.scope.EnvStr(tokenURL)
+	clientID = cmd.scope.EnvStr(clientID)
+	clientSecret = cmd.scope.EnvStr(clientSecret)
+	token, err := cmd.scope.sharedHTTP().token(cmd.scope, tokenURL, clientID, clientSecret, scopes)
+	if err != nil {
+		cmd.scope.SetErr(cmd.serr.fail("OAuth2", err))
+		return cmd
+	}
+	cmd.Req.Header.Set("Authorization", "Bearer "+token)
+	return cmd
+}
+
+func (h *httpState) token(s *Scope, tokenURL, clientID, clientSecret string, scopes []string) (string, error) {
+	key := strings.Join(append([]string{tokenURL, clientID}, scopes...), " ")
+	// held while fetching so concurrent requests share one token
+	h.tokenMu.Lock()
+	defer h.tokenMu.Unlock()
+	if t, ok := h.tokens[key]; ok && time.Now().Before(t.expires) {
+		return t.value, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(scopes) != 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+	// a child scope so failures can be reported as OAuth2 errors
+	fetch := s.Child().Propagate(PropagateNone)
+	defer fetch.End()
+	req := fetch.
+		Curl(tokenURL).
+		Post([]byte(form.Encode())).
+		Header("Content-Type", "application/x-www-form-urlencoded").
+		Header("Accept", "application/json")
+	if fetch.IsError() {
+		return "", fetch.Err()
+	}
+	// values have already been through EnvStr
+	req.Req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	resp := req.Response()
+	if err := fetch.Err(); err != nil {
+		return "", err
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(resp.BodyBytes(), &body); err != nil {
+		return "", xerrors.Errorf("token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", xerrors.New("token response has no access_token")
+	}
+	// without expires_in assume the token lasts an hour
+	t := &oauthToken{value: body.AccessToken, expires: time.Now().Add(time.Hour)}
+	if body.ExpiresIn > 0 {
+		lifetime := time.Duration(body.ExpiresIn) * time.Second
+		skew := tokenExpirySkew
+		if lifetime/2 < skew {
+			skew = lifetime / 2
+		}
+		t.expires = time.Now().Add(lifetime - skew)
+	}
+	if h.tokens == nil {
+		h.tokens = map[string]*oauthToken{}
+	}
+	h.tokens[key] = t
+	return t.value, nil
+}
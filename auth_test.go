@@ -0,0 +1,111 @@
+package lash_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_http_authentication(t *testing.T) {
+	t.Run("basic auth supports env vars", func(t *testing.T) {
+		require.NoError(t, os.Setenv("auth_test_password", "secret"))
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		req := scope.Curl("https://example.com").AuthBasic("user", "$auth_test_password")
+
+		user, pass, ok := req.Req.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "secret", pass)
+	})
+	t.Run("basic auth supports args", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		req := scope.Curl("https://example.com").AuthBasic("$0", "$1", "user", "secret")
+
+		user, pass, ok := req.Req.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "secret", pass)
+	})
+	t.Run("bearer token supports env vars", func(t *testing.T) {
+		require.NoError(t, os.Setenv("auth_test_token", "a-token"))
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		req := scope.Curl("https://example.com").AuthBearer("$auth_test_token")
+
+		assert.Equal(t, "Bearer a-token", req.Req.Header.Get("Authorization"))
+	})
+	t.Run("client credentials token is fetched once and cached", func(t *testing.T) {
+		fetched := 0
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/token":
+				fetched++
+				user, pass, _ := r.BasicAuth()
+				assert.Equal(t, "the-client", user)
+				assert.Equal(t, "the-secret", pass)
+				require.NoError(t, r.ParseForm())
+				assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+				assert.Equal(t, "read write", r.PostForm.Get("scope"))
+				_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, fetched)
+			default:
+				_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+			}
+		})
+		defer ts.Close()
+		require.NoError(t, os.Setenv("auth_test_secret", "the-secret"))
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		for i := 0; i < 2; i++ {
+			// child scopes share the token
+			child := scope.Child()
+			resp := child.
+				Curl(ts.URL+"/api").
+				AuthClientCredentials(ts.URL+"/token", "the-client", "$auth_test_secret", "read", "write").
+				Response()
+			child.End()
+
+			assert.Equal(t, "Bearer token-1", resp.BodyString())
+		}
+		assert.Equal(t, 1, fetched)
+	})
+	t.Run("expired tokens are fetched again", func(t *testing.T) {
+		fetched := 0
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			fetched++
+			_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":1}`, fetched)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		first := scope.Curl(ts.URL).AuthClientCredentials(ts.URL, "id", "secret")
+		// short lived tokens are refreshed half way through their lifetime
+		reused := scope.Curl(ts.URL).AuthClientCredentials(ts.URL, "id", "secret")
+		time.Sleep(600 * time.Millisecond)
+		second := scope.Curl(ts.URL).AuthClientCredentials(ts.URL, "id", "secret")
+
+		assert.Equal(t, "Bearer token-1", first.Req.Header.Get("Authorization"))
+		assert.Equal(t, "Bearer token-1", reused.Req.Header.Get("Authorization"))
+		assert.Equal(t, "Bearer token-2", second.Req.Header.Get("Authorization"))
+	})
+	t.Run("token failures are scope errors", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.Curl(ts.URL).AuthClientCredentials(ts.URL, "id", "secret")
+
+		require.Error(t, scope.Err())
+		assert.Contains(t, scope.Err().Error(), "HTTPRequest:OAuth2")
+		assert.Contains(t, scope.Err().Error(), "401")
+	})
+}
@@ -0,0 +1,326 @@
+package lash
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// httpCache stores GET responses in a directory, one file per url
+	httpCache struct {
+		dir string
+	}
+	// cacheEntry is the first line of a cache file, the body follows it
+	cacheEntry struct {
+		URL    string      `json:"url"`
+		Status int         `json:"status"`
+		Header http.Header `json:"header"`
+		Stored time.Time   `json:"stored"`
+		MaxAge int64       `json:"max_age"`
+		// request header values named by the Vary response header
+		Vary map[string]string `json:"vary,omitempty"`
+	}
+	cacheTransport struct {
+		cache *httpCache
+		next  http.RoundTripper
+	}
+	// cacheWriter copies the body to the cache as it is read, the entry is only kept
+	// if the whole body is read
+	cacheWriter struct {
+		io.ReadCloser
+		tmp  *os.File
+		path string
+		done bool
+		bad  bool
+	}
+)
+
+// Cache GET responses for this scope (and child scopes) in dir, it is created if required.
+// A response is fresh for the Cache-Control max-age, after that (or for no-cache) it is requested
+// again with If-None-Match and If-Modified-Since from the ETag and Last-Modified of the cached
+// response. A 304 Not Modified gives back the cached response. Responses with no-store are not cached,
+// nor are requests with an Authorization or Cookie header. A cached response is only used when the
+// request headers named by its Vary header are the same
+func (s *Scope) Cache(dir *File) *Scope {
+	if err := os.MkdirAll(dir.path, 0755); err != nil {
+		s.setErr("Scope", "Cache", err)
+		return s
+	}
+	h := s.ownHTTP()
+	h.mu.Lock()
+	h.cache = &httpCache{dir: dir.path}
+	h.mu.Unlock()
+	return s
+}
+
+// RoundTrip from the cache when fresh, otherwise a conditional request if possible
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheable(req) {
+		return t.next.RoundTrip(req)
+	}
+	path := t.cache.path(req.URL.String())
+	entry := t.cache.load(path)
+	if entry != nil && !entry.matches(req) {
+		entry = nil
+	}
+	if entry != nil && time.Since(entry.Stored) < time.Duration(entry.MaxAge)*time.Second {
+		if resp := t.cache.response(path, req); resp != nil {
+			return resp, nil
+		}
+	}
+
+	send := req
+	if entry != nil && (entry.Header.Get("ETag") != "" || entry.Header.Get("Last-Modified") != "") {
+		send = req.WithContext(req.Context())
+		send.Header = cloneHeader(req.Header)
+		if v := entry.Header.Get("ETag"); v != "" && send.Header.Get("If-None-Match") == "" {
+			send.Header.Set("If-None-Match", v)
+		}
+		if v := entry.Header.Get("Last-Modified"); v != "" && send.Header.Get("If-Modified-Since") == "" {
+			send.Header.Set("If-Modified-Since", v)
+		}
+	}
+	resp, err := t.next.RoundTrip(send)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+		// the 304 headers replace the cached ones, e.g. a new max-age
+		for name, values := range resp.Header {
+			entry.Header[name] = values
+		}
+		entry.Stored = time.Now()
+		entry.MaxAge = maxAge(entry.Header)
+		t.cache.update(path, entry)
+		if cached := t.cache.response(path, req); cached != nil {
+			return cached, nil
+		}
+		return nil, fmt.Errorf("cache entry for '%s' is missing", req.URL)
+	}
+	if resp.StatusCode == http.StatusOK && !hasDirective(resp.Header.Get("Cache-Control"), "no-store") && resp.Header.Get("Vary") != "*" {
+		resp.Body = t.cache.writer(path, resp)
+	}
+	return resp, nil
+}
+
+// cacheable requests, partial responses and responses for a user (credentials or cookies) are not cached
+func cacheable(req *http.Request) bool {
+	return req.Method == http.MethodGet &&
+		req.Header.Get("Range") == "" &&
+		req.Header.Get("Authorization") == "" &&
+		req.Header.Get("Cookie") == "" &&
+		!hasDirective(req.Header.Get("Cache-Control"), "no-store")
+}
+
+// matches the request when the headers named by Vary have the same values
+func (e *cacheEntry) matches(req *http.Request) bool {
+	for name, value := range e.Vary {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *httpCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// load the entry without the body, nil if it is not cached
+func (c *httpCache) load(path string) *cacheEntry {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+	entry, _ := readCacheEntry(bufio.NewReader(f))
+	return entry
+}
+
+func readCacheEntry(r *bufio.Reader) (*cacheEntry, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// response from the cache, nil if it can not be read
+func (c *httpCache) response(path string, req *http.Request) *http.Response {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	r := bufio.NewReader(f)
+	entry, err := readCacheEntry(r)
+	if err != nil {
+		_ = f.Close()
+		return nil
+	}
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", entry.Status, http.StatusText(entry.Status)),
+		StatusCode: entry.Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     entry.Header,
+		Body: struct {
+			io.Reader
+			io.Closer
+		}{r, f},
+		ContentLength: -1,
+		Request:       req,
+	}
+}
+
+// update the entry keeping the cached body
+func (c *httpCache) update(path string, entry *cacheEntry) {
+	in, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() { _ = in.Close() }()
+	r := bufio.NewReader(in)
+	if _, err := readCacheEntry(r); err != nil {
+		return
+	}
+	tmp, err := c.create(entry)
+	if err != nil {
+		return
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	commitCacheFile(tmp, path)
+}
+
+// create a temporary file in the cache with the entry written
+func (c *httpCache) create(entry *cacheEntry) (*os.File, error) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := ioutil.TempFile(c.dir, "tmp-")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// commitCacheFile replaces the cache file in one step so readers never see part of an entry
+func commitCacheFile(tmp *os.File, path string) {
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+// writer for the body of resp, if the cache can not be written to the body is returned unchanged
+func (c *httpCache) writer(path string, resp *http.Response) io.ReadCloser {
+	entry := &cacheEntry{
+		URL:    resp.Request.URL.String(),
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Stored: time.Now(),
+		MaxAge: maxAge(resp.Header),
+	}
+	for _, v := range resp.Header["Vary"] {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				if entry.Vary == nil {
+					entry.Vary = map[string]string{}
+				}
+				entry.Vary[http.CanonicalHeaderKey(name)] = resp.Request.Header.Get(name)
+			}
+		}
+	}
+	tmp, err := c.create(entry)
+	if err != nil {
+		return resp.Body
+	}
+	return &cacheWriter{ReadCloser: resp.Body, tmp: tmp, path: path}
+}
+
+func (w *cacheWriter) Read(p []byte) (int, error) {
+	n, err := w.ReadCloser.Read(p)
+	if n > 0 && !w.bad {
+		if _, werr := w.tmp.Write(p[:n]); werr != nil {
+			w.bad = true
+		}
+	}
+	if err == io.EOF {
+		w.done = true
+	}
+	return n, err
+}
+
+func (w *cacheWriter) Close() error {
+	err := w.ReadCloser.Close()
+	if w.tmp == nil {
+		return err
+	}
+	if w.done && !w.bad {
+		commitCacheFile(w.tmp, w.path)
+	} else {
+		_ = w.tmp.Close()
+		_ = os.Remove(w.tmp.Name())
+	}
+	w.tmp = nil
+	return err
+}
+
+// maxAge in seconds from Cache-Control, zero for no-cache
+func maxAge(header http.Header) int64 {
+	cc := header.Get("Cache-Control")
+	if hasDirective(cc, "no-cache") {
+		return 0
+	}
+	for _, d := range strings.Split(cc, ",") {
+		d = strings.TrimSpace(d)
+		if strings.HasPrefix(strings.ToLower(d), "max-age=") {
+			n, err := strconv.ParseInt(strings.Trim(d[len("max-age="):], `"`), 10, 64)
+			if err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+func hasDirective(cacheControl, directive string) bool {
+	for _, d := range strings.Split(cacheControl, ",") {
+		if strings.EqualFold(strings.TrimSpace(d), directive) {
+			return true
+		}
+	}
+	return false
+}
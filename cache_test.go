@@ -0,0 +1,143 @@
+package lash_test
+
+import (
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_http_cache(t *testing.T) {
+	t.Run("a fresh response is not requested again", func(t *testing.T) {
+		dir := tempPathname()
+		defer func() { _ = os.RemoveAll(dir) }()
+		var calls int32
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte("reference data"))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+		scope.Cache(scope.OpenFile(dir))
+
+		first := scope.Curl(ts.URL).Response()
+		second := scope.Curl(ts.URL).Response()
+		// a new scope with the same directory, as if the script was run again
+		again := lash.NewScope().OnError(requireNoError(t))
+		third := again.Cache(again.OpenFile(dir)).Curl(ts.URL).Response()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		assert.Equal(t, "reference data", first.BodyString())
+		assert.Equal(t, "reference data", second.BodyString())
+		assert.Equal(t, "reference data", third.BodyString())
+		assert.Equal(t, http.StatusOK, third.StatusCode())
+		assert.Equal(t, "max-age=60", third.Header("Cache-Control"))
+	})
+	t.Run("a stale response is revalidated", func(t *testing.T) {
+		dir := tempPathname()
+		defer func() { _ = os.RemoveAll(dir) }()
+		var calls, notModified int32
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "no-cache")
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			_, _ = w.Write([]byte("version one"))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+		scope.Cache(scope.OpenFile(dir))
+
+		scope.Curl(ts.URL).Response()
+		resp := scope.Curl(ts.URL).Response()
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+		assert.Equal(t, "version one", resp.BodyString())
+	})
+	t.Run("last modified is used when there is no etag", func(t *testing.T) {
+		dir := tempPathname()
+		defer func() { _ = os.RemoveAll(dir) }()
+		var since string
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			since = r.Header.Get("If-Modified-Since")
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			_, _ = w.Write([]byte("data"))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+		scope.Cache(scope.OpenFile(dir))
+
+		scope.Curl(ts.URL).Response()
+		scope.Curl(ts.URL).Response()
+
+		assert.Equal(t, "Mon, 02 Jan 2006 15:04:05 GMT", since)
+	})
+	t.Run("no-store and other methods are not cached", func(t *testing.T) {
+		dir := tempPathname()
+		defer func() { _ = os.RemoveAll(dir) }()
+		var calls int32
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			if r.Method == http.MethodGet {
+				w.Header().Set("Cache-Control", "no-store, max-age=60")
+			} else {
+				w.Header().Set("Cache-Control", "max-age=60")
+			}
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+		scope.Cache(scope.OpenFile(dir))
+
+		scope.Curl(ts.URL).Response()
+		scope.Curl(ts.URL).Response()
+		scope.Curl(ts.URL).Post([]byte("x")).Response()
+		scope.Curl(ts.URL).Post([]byte("x")).Response()
+
+		assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+	})
+	t.Run("requests with credentials are not cached", func(t *testing.T) {
+		dir := tempPathname()
+		defer func() { _ = os.RemoveAll(dir) }()
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte("secret for " + r.Header.Get("Authorization")))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+		scope.Cache(scope.OpenFile(dir))
+
+		scope.Curl(ts.URL).AuthBearer("alice").Response()
+		resp := scope.Curl(ts.URL).AuthBearer("bob").Response()
+
+		assert.Equal(t, "secret for Bearer bob", resp.BodyString())
+	})
+	t.Run("vary headers must match", func(t *testing.T) {
+		dir := tempPathname()
+		defer func() { _ = os.RemoveAll(dir) }()
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+			_, _ = w.Write([]byte("lang " + r.Header.Get("Accept-Language")))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+		scope.Cache(scope.OpenFile(dir))
+
+		scope.Curl(ts.URL).Header("Accept-Language", "en").Response()
+		fr := scope.Curl(ts.URL).Header("Accept-Language", "fr").Response()
+		fr2 := scope.Curl(ts.URL).Header("Accept-Language", "fr").Response()
+
+		assert.Equal(t, "lang fr", fr.BodyString())
+		assert.Equal(t, "lang fr", fr2.BodyString())
+	})
+}
@@ -0,0 +1,100 @@
+package lash
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// PropagateMode controls how a child scope passes its errors to the parent
+type PropagateMode int
+
+const (
+	// PropagateOnEnd passes every error (the last 100) to the parent when End is called, the default
+	PropagateOnEnd PropagateMode = iota
+	// PropagateEach passes each error to the parent as it happens
+	PropagateEach
+	// PropagateSummary passes a single error to the parent when End is called,
+	// e.g. "3 of 10 items failed"
+	PropagateSummary
+	// PropagateNone never passes errors to the parent
+	PropagateNone
+)
+
+// Child scope writes to the outputs of this scope until SetOutput or SetErrOutput is called on the child,
+// it shares the http settings, Verbose and DryRun of this scope but has its own error state and no OnError func,
+// errors are passed to this scope as per Propagate. Http settings changed with the child only apply to the child.
+// The context of the child is cancelled when this scope is cancelled or the child Ends, so always call End
+func (s *Scope) Child() *Scope {
+	outMu := &sync.Mutex{}
+	s.mu.Lock()
+	c := &Scope{
+		stdout:  &lockedWriter{mu: outMu, w: s.stdout},
+		stderr:  &lockedWriter{mu: outMu, w: s.stderr},
+		parent:  s,
+		http:    s.http,
+		verbose: s.verbose,
+		dryRun:  s.dryRun,
+	}
+	s.mu.Unlock()
+	c.ctx, c.cancel = context.WithCancel(s.Context())
+	return c
+}
+
+// Sub runs fn with a Child scope then Ends it, returns the errors of the child
+func (s *Scope) Sub(fn func(s *Scope)) error {
+	child := s.Child()
+	fn(child)
+	child.End()
+	return child.Err()
+}
+
+// Propagate sets how errors are passed to the parent, no effect if this is not a Child scope
+func (s *Scope) Propagate(mode PropagateMode) *Scope {
+	s.mu.Lock()
+	s.propagate = mode
+	s.mu.Unlock()
+	return s
+}
+
+// End a child scope, errors are passed to the parent unless the mode is PropagateEach
+// or PropagateNone. Each ended child counts as an item of the parent for PropagateSummary
+func (s *Scope) End() {
+	s.mu.Lock()
+	if s.parent == nil || s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	errs := s.unsent
+	s.unsent = nil
+	count, first := s.errCount, s.firstErr
+	mode, items, failed := s.propagate, s.items, s.failed
+	cancel := s.cancel
+	s.mu.Unlock()
+	cancel()
+
+	parent := s.parent
+	parent.mu.Lock()
+	parent.items++
+	if count != 0 {
+		parent.failed++
+	}
+	parent.mu.Unlock()
+
+	switch mode {
+	case PropagateOnEnd:
+		for _, err := range errs {
+			parent.SetErr(err)
+		}
+	case PropagateSummary:
+		if count == 0 {
+			return
+		}
+		summary := fmt.Errorf("%d errors, first: %v", count, first)
+		if items != 0 {
+			summary = fmt.Errorf("%d of %d items failed, first: %v", failed, items, first)
+		}
+		parent.SetErr(&ScopeErr{Type: "Scope", Action: "Summary", Err: summary})
+	}
+}
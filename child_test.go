@@ -0,0 +1,111 @@
+package lash_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_child_scopes(t *testing.T) {
+	t.Run("child shares the outputs of the parent", func(t *testing.T) {
+		var buf bytes.Buffer
+		scope := lash.NewScope().SetOutput(&buf)
+
+		scope.Child().Println("from the child")
+
+		assert.Equal(t, "from the child\n", buf.String())
+	})
+	t.Run("changing the output of the child does not change the parent", func(t *testing.T) {
+		var parentBuf, childBuf bytes.Buffer
+		scope := lash.NewScope().SetOutput(&parentBuf)
+
+		child := scope.Child().SetOutput(&childBuf)
+		scope.Println("from the parent")
+		child.Println("from the child")
+
+		assert.Equal(t, "from the parent\n", parentBuf.String())
+		assert.Equal(t, "from the child\n", childBuf.String())
+	})
+	t.Run("child has its own error handler and errors reach the parent when it ends", func(t *testing.T) {
+		var parentErrs, childErrs []error
+		scope := lash.NewScope().OnError(func(err error) { parentErrs = append(parentErrs, err) })
+
+		child := scope.Child()
+		child.OnError(func(err error) { childErrs = append(childErrs, err) })
+		child.SetErr(fmt.Errorf("one"))
+		child.SetErr(fmt.Errorf("two"))
+
+		assert.Len(t, childErrs, 2)
+		assert.Empty(t, parentErrs)
+		assert.False(t, scope.IsError())
+
+		child.End()
+		child.End()
+
+		assert.Len(t, parentErrs, 2)
+	})
+	t.Run("only the last 100 errors reach the parent", func(t *testing.T) {
+		var parentErrs []error
+		scope := lash.NewScope().OnError(func(err error) { parentErrs = append(parentErrs, err) })
+
+		child := scope.Child().OnError(lash.Ignore)
+		for i := 0; i < 150; i++ {
+			child.SetErr(fmt.Errorf("error %d", i))
+		}
+		child.End()
+
+		require.Len(t, parentErrs, 100)
+		assert.EqualError(t, parentErrs[0], "error 50")
+		assert.EqualError(t, parentErrs[99], "error 149")
+	})
+	t.Run("errors can be passed up as they happen", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		child := scope.Child().Propagate(lash.PropagateEach)
+		child.SetErr(fmt.Errorf("now"))
+
+		assert.EqualError(t, scope.Err(), "now")
+		child.End()
+		assert.EqualError(t, scope.Err(), "now")
+	})
+	t.Run("errors can be kept in the child", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		err := scope.Sub(func(s *lash.Scope) {
+			s.Propagate(lash.PropagateNone)
+			s.SetErr(fmt.Errorf("stays here"))
+		})
+
+		assert.EqualError(t, err, "stays here")
+		assert.NoError(t, scope.Err())
+	})
+	t.Run("errors can be summarised as items that failed", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		batch := scope.Child().Propagate(lash.PropagateSummary)
+		batch.ForEach(numberedLines(10), 3, func(s *lash.Scope, line string) {
+			if line == "line 2" || line == "line 4" {
+				s.SetErr(fmt.Errorf("bad %s", line))
+			}
+		})
+		batch.End()
+
+		require.Error(t, scope.Err())
+		assert.Contains(t, scope.Err().Error(), "Scope:Summary:2 of 10 items failed")
+	})
+	t.Run("summary without items counts the errors", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.Sub(func(s *lash.Scope) {
+			s.Propagate(lash.PropagateSummary)
+			s.SetErr(fmt.Errorf("first"))
+			s.SetErr(fmt.Errorf("second"))
+		})
+
+		assert.Contains(t, scope.Err().Error(), "2 errors, first: first")
+	})
+}
@@ -0,0 +1,76 @@
+package lash
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// Context of the scope, cancelling it stops http requests, commands, ReadLines, Appenders and ForEach
+func (s *Scope) Context() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+	return s.ctx
+}
+
+// WithContext replaces the context of the scope, the previous context is cancelled so operations
+// and Child scopes started before this call are stopped. Child scopes made after follow ctx
+func (s *Scope) WithContext(ctx context.Context) *Scope {
+	s.mu.Lock()
+	previous := s.cancel
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.mu.Unlock()
+	if previous != nil {
+		previous()
+	}
+	return s
+}
+
+// WithTimeout for everything done with this scope from now on, the new context is derived from
+// the current one. Child scopes made before this call are not affected by the timeout but are
+// still cancelled by Cancel
+func (s *Scope) WithTimeout(d time.Duration) *Scope {
+	s.mu.Lock()
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+	previous := s.cancel
+	ctx, cancel := context.WithTimeout(s.ctx, d)
+	s.ctx = ctx
+	s.cancel = func() {
+		cancel()
+		previous()
+	}
+	s.mu.Unlock()
+	return s
+}
+
+// Cancel the context of the scope (and any Child scopes)
+func (s *Scope) Cancel() {
+	_ = s.Context()
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	cancel()
+}
+
+// CancelOnInterrupt cancels the scope when Ctrl-C (SIGINT) is received so the script can stop cleanly,
+// a second Ctrl-C is not caught
+func (s *Scope) CancelOnInterrupt() *Scope {
+	ctx := s.Context()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		defer signal.Stop(sig)
+		select {
+		case <-sig:
+			s.Cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return s
+}
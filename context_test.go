@@ -0,0 +1,144 @@
+package lash_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeHangingServer() *httptest.Server {
+	return makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+}
+
+func Test_scope_context(t *testing.T) {
+	t.Run("scope timeout stops http requests", func(t *testing.T) {
+		ts := makeHangingServer()
+		defer ts.Close()
+		scope := lash.NewScope().OnError(lash.Ignore).WithTimeout(50 * time.Millisecond)
+
+		start := time.Now()
+		scope.Curl(ts.URL).Response()
+
+		assert.True(t, time.Since(start) < time.Second)
+		require.Error(t, scope.Err())
+		assert.Contains(t, scope.Err().Error(), "deadline exceeded")
+	})
+	t.Run("requests can have their own timeout", func(t *testing.T) {
+		ts := makeHangingServer()
+		defer ts.Close()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		start := time.Now()
+		scope.Curl(ts.URL).Timeout(50 * time.Millisecond).Response()
+
+		assert.True(t, time.Since(start) < time.Second)
+		assert.Contains(t, scope.Err().Error(), "HTTPRequest:Send")
+	})
+	t.Run("cancelling stops reading lines", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, "1\n2\n3\n4\n")()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		ch := scope.OpenFile(filename).ReadLines()
+		assert.Equal(t, "1", <-ch)
+		scope.Cancel()
+
+		for range ch {
+		}
+		assert.Contains(t, scope.Err().Error(), "File:ReadLines:context canceled")
+	})
+	t.Run("cancelling kills commands", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore).WithTimeout(50 * time.Millisecond)
+
+		start := time.Now()
+		scope.Exec("sleep 5").Run()
+
+		assert.True(t, time.Since(start) < time.Second)
+		assert.Contains(t, scope.Err().Error(), "Job:Run")
+	})
+	t.Run("child scopes are cancelled with the parent", func(t *testing.T) {
+		scope := lash.NewScope().WithContext(context.Background())
+		child := scope.Child()
+		defer child.End()
+
+		scope.Cancel()
+
+		assert.Error(t, child.Context().Err())
+	})
+	t.Run("replacing the context cancels the previous one", func(t *testing.T) {
+		scope := lash.NewScope()
+		before := scope.Context()
+
+		scope.WithContext(context.Background())
+
+		assert.Error(t, before.Err())
+		assert.NoError(t, scope.Context().Err())
+	})
+	t.Run("cancel reaches child scopes made before a timeout", func(t *testing.T) {
+		scope := lash.NewScope()
+		child := scope.Child()
+		defer child.End()
+
+		scope.WithTimeout(time.Hour).Cancel()
+
+		assert.Error(t, child.Context().Err())
+	})
+	t.Run("cancelling ForEach lets the sender finish", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore)
+		scope.Cancel()
+		ch := make(chan string)
+		sent := make(chan struct{})
+		go func() {
+			defer close(sent)
+			defer close(ch)
+			for i := 0; i < 100; i++ {
+				ch <- "line"
+			}
+		}()
+
+		scope.ForEach(ch, 2, func(s *lash.Scope, line string) {})
+
+		select {
+		case <-sent:
+		case <-time.After(time.Second):
+			assert.Fail(t, "sender is blocked")
+		}
+	})
+	t.Run("a finished appender is not an error when cancelled", func(t *testing.T) {
+		filename := tempPathname()
+		defer func() { _ = os.Remove(filename) }()
+		var errs []error
+		scope := lash.NewScope().OnError(func(err error) { errs = append(errs, err) })
+		appender := scope.OpenFile(filename).Appender()
+		appender.AppendLine("one")
+
+		scope.Cancel()
+		appender.Close()
+
+		assert.Empty(t, errs)
+	})
+	t.Run("interrupt cancels the scope", func(t *testing.T) {
+		scope := lash.NewScope().CancelOnInterrupt()
+
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+
+		select {
+		case <-scope.Context().Done():
+		case <-time.After(time.Second):
+			assert.Fail(t, "scope was not cancelled")
+		}
+	})
+}
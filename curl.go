@@ -2,10 +2,14 @@ package lash
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 )
 
 type (
@@ -15,6 +19,9 @@ type (
 		serr     ScopeErr
 		Req      *http.Request
 		statuses []int
+		retry    retryPolicy
+		timeout  time.Duration
+		parts    *multipartBody
 		Client   *http.Client
 	}
 	// HTTPResponse from a request
@@ -22,6 +29,22 @@ type (
 		scope    *Scope
 		response *http.Response
 		body     []byte
+		duration time.Duration
+	}
+	// httpState is shared by a scope and its children until a child changes it
+	httpState struct {
+		mu       sync.Mutex
+		jar      http.CookieJar
+		fixtures *fixtures
+		cache    *httpCache
+		config   httpConfig
+		shared   *http.Client
+		// rate limits for requests
+		limit      *rateLimiter
+		hostLimits map[string]*rateLimiter
+		slots      chan struct{}
+		tokenMu    sync.Mutex
+		tokens     map[string]*oauthToken
 	}
 )
 
@@ -29,16 +52,21 @@ const AnyHTTPStatus = 9999
 
 // Curl for this scope
 func (s *Scope) Curl(url string, args ...interface{}) *HTTPRequest {
+	return s.newHTTPRequest("Curl", s.EnvStr(url, args...))
+}
+
+func (s *Scope) newHTTPRequest(action, url string) *HTTPRequest {
 	serr := ScopeErr{Type: "HTTPRequest"}
-	req, err := http.NewRequest("GET", s.EnvStr(url, args...), nil)
+	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		s.SetErr(serr.fail("Curl", err))
+		s.SetErr(serr.fail(action, err))
 	}
 	return &HTTPRequest{
 		serr:     serr,
 		scope:    s,
 		Req:      req,
 		statuses: []int{200, 201, 202, 204},
+		retry:    defaultRetryPolicy(),
 	}
 }
 
@@ -52,16 +80,62 @@ func (cmd *HTTPRequest) Put(body []byte) *HTTPRequest {
 	return cmd.Method(http.MethodPut, body)
 }
 
+// Patch method will be used
+func (cmd *HTTPRequest) Patch(body []byte) *HTTPRequest {
+	return cmd.Method(http.MethodPatch, body)
+}
+
 // Delete method will be used
 func (cmd *HTTPRequest) Delete() *HTTPRequest {
 	return cmd.Method(http.MethodDelete, nil)
 }
 
+// Head method will be used
+func (cmd *HTTPRequest) Head() *HTTPRequest {
+	return cmd.Method(http.MethodHead, nil)
+}
+
+// Options method will be used
+func (cmd *HTTPRequest) Options() *HTTPRequest {
+	return cmd.Method(http.MethodOptions, nil)
+}
+
+// PostJSON method will be used with v as a json body
+func (cmd *HTTPRequest) PostJSON(v interface{}) *HTTPRequest {
+	return cmd.MethodJSON(http.MethodPost, v)
+}
+
+// PutJSON method will be used with v as a json body
+func (cmd *HTTPRequest) PutJSON(v interface{}) *HTTPRequest {
+	return cmd.MethodJSON(http.MethodPut, v)
+}
+
+// PatchJSON method will be used with v as a json body
+func (cmd *HTTPRequest) PatchJSON(v interface{}) *HTTPRequest {
+	return cmd.MethodJSON(http.MethodPatch, v)
+}
+
+// MethodJSON marshals v as the body, Content-Type and Accept are set to application/json
+func (cmd *HTTPRequest) MethodJSON(method string, v interface{}) *HTTPRequest {
+	b, err := json.Marshal(v)
+	if err != nil {
+		cmd.scope.SetErr(cmd.serr.fail("MarshalJSON", err))
+		return cmd
+	}
+	cmd.Req.Header.Set("Content-Type", "application/json")
+	cmd.Req.Header.Set("Accept", "application/json")
+	return cmd.Method(method, b)
+}
+
 // Method can be any
 func (cmd *HTTPRequest) Method(method string, body []byte) *HTTPRequest {
 	cmd.Req.Method = method
 	if body != nil {
-		cmd.Req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		cmd.Req.ContentLength = int64(len(body))
+		cmd.Req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		cmd.Req.Body, _ = cmd.Req.GetBody()
 	}
 	return cmd
 }
@@ -72,27 +146,28 @@ func (cmd *HTTPRequest) AllowResponses(status ...int) *HTTPRequest {
 	return cmd
 }
 
+// Timeout for this request including reading the response, the scope context also applies
+func (cmd *HTTPRequest) Timeout(d time.Duration) *HTTPRequest {
+	cmd.timeout = d
+	return cmd
+}
+
 // Response the request
 func (cmd *HTTPRequest) Response() *HTTPResponse {
 	r := &HTTPResponse{scope: cmd.scope}
-	if cmd.Client == nil {
-		cmd.Client = &http.Client{}
-	}
-	var err error
-	r.response, err = cmd.Client.Do(cmd.Req)
-	if err != nil {
-		cmd.scope.SetErr(cmd.serr.fail("Send", err))
-		return r
-	}
-
-	if !isInList(r.response.StatusCode, cmd.statuses) {
-		err = fmt.Errorf("status %v not allowed", r.response.StatusCode)
-		cmd.scope.SetErr(cmd.serr.fail("Send", err))
+	ctx, cancel := cmd.context()
+	defer cancel()
+	start := time.Now()
+	defer func() { r.duration = time.Since(start) }()
+	var ok bool
+	r.response, ok = cmd.send(ctx)
+	if !ok {
 		return r
 	}
 
 	if r.response.Body != nil {
 		defer func() { _ = r.response.Body.Close() }()
+		var err error
 		r.body, err = ioutil.ReadAll(r.response.Body)
 		if err != nil {
 			cmd.scope.SetErr(cmd.serr.fail("ReadBody", err))
@@ -102,6 +177,88 @@ func (cmd *HTTPRequest) Response() *HTTPResponse {
 	return r
 }
 
+// send the request, retrying as required, the response body is left for the caller to read.
+// The response is nil if nothing was received, ok is false if the status is not allowed
+func (cmd *HTTPRequest) send(ctx context.Context) (*http.Response, bool) {
+	client := cmd.Client
+	if client == nil {
+		client = cmd.scope.sharedHTTP().sharedClient()
+	}
+	client = cmd.scope.sharedHTTP().client(client)
+	req := cmd.Req.WithContext(ctx)
+	if cmd.scope.IsDryRun() {
+		cmd.scope.logRequest("[dry-run] ", req)
+		return nil, false
+	}
+	verbose := cmd.scope.isVerbose()
+	for attempt := 0; ; attempt++ {
+		// bodies are rewound for retries, streamed bodies (e.g. Multipart) are only created here
+		if (attempt > 0 || req.Body == nil) && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cmd.scope.SetErr(cmd.serr.fail("Retry", err))
+				return nil, false
+			}
+			req.Body = body
+		}
+		if verbose {
+			cmd.scope.logRequest("> ", req)
+		}
+		release, err := cmd.scope.sharedHTTP().acquire(ctx, req.URL)
+		if err != nil {
+			cmd.scope.SetErr(cmd.serr.fail("RateLimit", err))
+			return nil, false
+		}
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			release()
+		} else {
+			resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+		}
+		if verbose {
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.scope.stderr, "< %v\n", err)
+			} else {
+				_, _ = fmt.Fprintf(cmd.scope.stderr, "< %s %v\n", resp.Status, time.Since(start))
+			}
+		}
+		if attempt < cmd.retry.attempts && ctx.Err() == nil && cmd.retry.retryable(resp, err) {
+			wait := cmd.retry.wait(attempt, resp)
+			if resp != nil {
+				_, _ = io.Copy(ioutil.Discard, resp.Body)
+				_ = resp.Body.Close()
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				cmd.scope.SetErr(cmd.serr.fail("Send", ctx.Err()))
+				return nil, false
+			}
+			continue
+		}
+		if err != nil {
+			cmd.scope.SetErr(cmd.serr.fail("Send", err))
+			return nil, false
+		}
+		if !isInList(resp.StatusCode, cmd.statuses) {
+			_ = resp.Body.Close()
+			err = fmt.Errorf("status %v not allowed", resp.StatusCode)
+			cmd.scope.SetErr(cmd.serr.fail("Send", err))
+			return resp, false
+		}
+		return resp, true
+	}
+}
+
+// context for sending the request, from the scope with the request timeout if set
+func (cmd *HTTPRequest) context() (context.Context, context.CancelFunc) {
+	if cmd.timeout > 0 {
+		return context.WithTimeout(cmd.scope.Context(), cmd.timeout)
+	}
+	return context.WithCancel(cmd.scope.Context())
+}
+
 // Header can be set, this overwrites and previous value
 func (cmd *HTTPRequest) Header(name, value string, args ...interface{}) *HTTPRequest {
 	cmd.Req.Header.Set(name, cmd.scope.EnvStr(value, args...))
@@ -147,7 +304,7 @@ func (r *HTTPResponse) BodyBytes() []byte {
 }
 
 func (r *HTTPResponse) IsError() bool {
-	return r.scope != nil && r.scope.err != nil
+	return r.scope.IsError()
 }
 
 // BodyJSON puts the response body into the passed in type
@@ -1,6 +1,7 @@
 package lash_test
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -158,6 +159,48 @@ func Test_http_requests(t *testing.T) {
 			assert.Equal(t, 200, resp.StatusCode())
 		})
 	})
+	t.Run("can send json", func(t *testing.T) {
+		var method, contentType, accept string
+		var actual map[string]interface{}
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			contentType = r.Header.Get("Content-Type")
+			accept = r.Header.Get("Accept")
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&actual))
+		})
+		defer ts.Close()
+
+		body := map[string]interface{}{"name": "any"}
+		send := map[string]func(*lash.HTTPRequest){
+			"POST":  func(r *lash.HTTPRequest) { r.PostJSON(body) },
+			"PUT":   func(r *lash.HTTPRequest) { r.PutJSON(body) },
+			"PATCH": func(r *lash.HTTPRequest) { r.PatchJSON(body) },
+		}
+		for expected, fn := range send {
+			scope := lash.NewScope().OnError(requireNoError(t))
+			scope.Curl(ts.URL).CommonFunc(fn).Response()
+
+			assert.Equal(t, expected, method)
+			assert.Equal(t, "application/json", contentType)
+			assert.Equal(t, "application/json", accept)
+			assert.Equal(t, "any", actual["name"])
+		}
+	})
+	t.Run("json that cannot be marshalled is an error", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.Curl("https://example.com").PostJSON(func() {})
+
+		require.Error(t, scope.Err())
+		assert.Contains(t, scope.Err().Error(), "HTTPRequest:MarshalJSON")
+	})
+	t.Run("other methods have shortcuts", func(t *testing.T) {
+		scope := lash.NewScope()
+
+		assert.Equal(t, "PATCH", scope.Curl("https://example.com").Patch([]byte("{}")).Req.Method)
+		assert.Equal(t, "HEAD", scope.Curl("https://example.com").Head().Req.Method)
+		assert.Equal(t, "OPTIONS", scope.Curl("https://example.com").Options().Req.Method)
+	})
 	t.Run("request can contain any header", func(t *testing.T) {
 		called := 0
 		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,365 @@
+package lash
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type (
+	// curlOptions collected from a curl command line before the request is built
+	curlOptions struct {
+		url     string
+		method  string
+		head    bool
+		get     bool
+		json    bool
+		user    string
+		timeout time.Duration
+		headers [][2]string
+		data    []string
+		form    []multipartPart
+	}
+)
+
+// long curl options that have a short form
+var curlAliases = map[string]string{
+	"--request":    "-X",
+	"--header":     "-H",
+	"--data":       "-d",
+	"--form":       "-F",
+	"--user":       "-u",
+	"--user-agent": "-A",
+	"--referer":    "-e",
+	"--cookie":     "-b",
+	"--head":       "-I",
+	"--get":        "-G",
+	"--max-time":   "-m",
+	"--output":     "-o",
+	"--location":   "-L",
+	"--insecure":   "-k",
+	"--silent":     "-s",
+	"--show-error": "-S",
+	"--include":    "-i",
+	"--verbose":    "-v",
+	"--fail":       "-f",
+	"--globoff":    "-g",
+}
+
+// options that take a value
+var curlValueOptions = map[string]bool{
+	"-X": true, "-H": true, "-d": true, "-F": true, "-u": true, "-A": true, "-e": true, "-b": true, "-m": true, "-o": true,
+	"--data-raw": true, "--data-binary": true, "--data-ascii": true, "--data-urlencode": true, "--form-string": true,
+	"--json": true, "--url": true, "--connect-timeout": true,
+}
+
+// options that only change how curl itself behaves, the value (if any) is skipped
+var curlIgnoredOptions = map[string]bool{
+	"-L": true, "-k": true, "-s": true, "-S": true, "-i": true, "-v": true, "-f": true, "-g": true, "-o": true,
+	"--compressed": true, "--connect-timeout": true, "--http1.1": true, "--http2": true,
+}
+
+// AsCurl the equivalent curl command line, e.g. for bug reports. Header values including
+// Authorization are included as is
+func (cmd *HTTPRequest) AsCurl() string {
+	if cmd.Req == nil {
+		return "curl"
+	}
+	parts := []string{"curl"}
+	switch cmd.Req.Method {
+	case "", http.MethodGet:
+	case http.MethodHead:
+		parts = append(parts, "-I")
+	default:
+		parts = append(parts, "-X", cmd.Req.Method)
+	}
+
+	names := make([]string, 0, len(cmd.Req.Header))
+	for name := range cmd.Req.Header {
+		// curl sets its own multipart boundary
+		if cmd.parts != nil && http.CanonicalHeaderKey(name) == "Content-Type" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range cmd.Req.Header[name] {
+			parts = append(parts, "-H", shellQuote(name+": "+v))
+		}
+	}
+
+	if cmd.parts != nil {
+		for _, p := range cmd.parts.parts {
+			if p.file != nil {
+				parts = append(parts, "-F", shellQuote(p.name+"=@"+p.file.path))
+			} else {
+				parts = append(parts, "--form-string", shellQuote(p.name+"="+p.value))
+			}
+		}
+	} else if cmd.Req.GetBody != nil {
+		body, err := cmd.Req.GetBody()
+		if err == nil {
+			b, _ := ioutil.ReadAll(body)
+			_ = body.Close()
+			parts = append(parts, "--data-raw", shellQuote(string(b)))
+		}
+	}
+	if cmd.timeout > 0 {
+		parts = append(parts, "--max-time", strconv.FormatFloat(cmd.timeout.Seconds(), 'f', -1, 64))
+	}
+	return strings.Join(append(parts, shellQuote(cmd.Req.URL.String())), " ")
+}
+
+// FromCurl builds a request from a curl command line such as one copied from a browser or Postman.
+// The command line is used as is, it does not support EnvStr. Options that only change how
+// curl itself behaves (-s, -L, -k, --compressed etc.) are ignored, other unknown options are an error
+func (s *Scope) FromCurl(cmdline string) *HTTPRequest {
+	opts, err := parseCurl(cmdline)
+	if err != nil {
+		cmd := s.newHTTPRequest("FromCurl", "")
+		s.SetErr(cmd.serr.fail("FromCurl", err))
+		return cmd
+	}
+	cmd := s.newHTTPRequest("FromCurl", opts.url)
+	if cmd.Req == nil {
+		return cmd
+	}
+	opts.apply(cmd)
+	return cmd
+}
+
+func parseCurl(cmdline string) (*curlOptions, error) {
+	args, err := splitArgs(cmdline)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, xerrors.Errorf("not a curl command '%s'", cmdline)
+	}
+	args = expandCurlArgs(args[1:])
+
+	opts := &curlOptions{}
+	for i := 0; i < len(args); i++ {
+		name := args[i]
+		if !strings.HasPrefix(name, "-") {
+			if opts.url != "" {
+				return nil, xerrors.Errorf("more than one url '%s' and '%s'", opts.url, name)
+			}
+			opts.url = name
+			continue
+		}
+		value := ""
+		if curlValueOptions[name] {
+			if i+1 == len(args) {
+				return nil, xerrors.Errorf("option '%s' has no value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if err := opts.set(name, value); err != nil {
+			return nil, err
+		}
+	}
+	if opts.url == "" {
+		return nil, xerrors.New("no url")
+	}
+	return opts, nil
+}
+
+// expandCurlArgs so every option is in its short form (where it has one) with the value as the next argument,
+// e.g. "-sSL" becomes "-s -S -L" and "-XPOST" becomes "-X POST"
+func expandCurlArgs(args []string) []string {
+	var expanded []string
+	for _, arg := range args {
+		if short, ok := curlAliases[arg]; ok {
+			expanded = append(expanded, short)
+			continue
+		}
+		if len(arg) <= 2 || arg[0] != '-' || arg[1] == '-' {
+			expanded = append(expanded, arg)
+			continue
+		}
+		for i := 1; i < len(arg); i++ {
+			short := "-" + arg[i:i+1]
+			expanded = append(expanded, short)
+			if curlValueOptions[short] {
+				if i+1 < len(arg) {
+					expanded = append(expanded, arg[i+1:])
+				}
+				break
+			}
+		}
+	}
+	return expanded
+}
+
+func (opts *curlOptions) set(name, value string) error {
+	switch name {
+	case "-X":
+		opts.method = strings.ToUpper(value)
+	case "-H":
+		i := strings.Index(value, ":")
+		if i < 1 {
+			return xerrors.Errorf("header '%s' has no value", value)
+		}
+		opts.header(value[:i], strings.TrimSpace(value[i+1:]))
+	case "-A":
+		opts.header("User-Agent", value)
+	case "-e":
+		opts.header("Referer", value)
+	case "-b":
+		if !strings.Contains(value, "=") {
+			return xerrors.Errorf("cookie files are not supported '%s'", value)
+		}
+		opts.header("Cookie", value)
+	case "-u":
+		opts.user = value
+	case "-I":
+		opts.head = true
+	case "-G":
+		opts.get = true
+	case "-m":
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return xerrors.Errorf("max-time '%s': %w", value, err)
+		}
+		opts.timeout = time.Duration(seconds * float64(time.Second))
+	case "--url":
+		opts.url = value
+	case "-d", "--data-ascii", "--data-binary", "--data-raw", "--json":
+		if strings.HasPrefix(value, "@") && name != "--data-raw" {
+			b, err := ioutil.ReadFile(value[1:])
+			if err != nil {
+				return err
+			}
+			value = string(b)
+			// as curl, only binary data keeps its line breaks
+			if name != "--data-binary" {
+				value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+			}
+		}
+		opts.data = append(opts.data, value)
+		opts.json = opts.json || name == "--json"
+	case "--data-urlencode":
+		opts.data = append(opts.data, curlURLEncode(value))
+	case "-F":
+		i := strings.Index(value, "=")
+		if i < 1 {
+			return xerrors.Errorf("form field '%s' has no value", value)
+		}
+		part := multipartPart{name: value[:i], value: value[i+1:]}
+		if strings.HasPrefix(part.value, "@") {
+			// drop ;type= and ;filename= settings
+			path := strings.SplitN(part.value[1:], ";", 2)[0]
+			part.file = &File{path: path}
+		}
+		opts.form = append(opts.form, part)
+	case "--form-string":
+		i := strings.Index(value, "=")
+		if i < 1 {
+			return xerrors.Errorf("form field '%s' has no value", value)
+		}
+		opts.form = append(opts.form, multipartPart{name: value[:i], value: value[i+1:]})
+	default:
+		if !curlIgnoredOptions[name] {
+			return xerrors.Errorf("unsupported option '%s'", name)
+		}
+	}
+	return nil
+}
+
+func (opts *curlOptions) header(name, value string) {
+	opts.headers = append(opts.headers, [2]string{name, value})
+}
+
+func (opts *curlOptions) apply(cmd *HTTPRequest) {
+	for _, h := range opts.headers {
+		cmd.Req.Header.Add(h[0], h[1])
+	}
+	if i := strings.Index(opts.user, ":"); i >= 0 {
+		cmd.Req.SetBasicAuth(opts.user[:i], opts.user[i+1:])
+	} else if opts.user != "" {
+		cmd.Req.SetBasicAuth(opts.user, "")
+	}
+	cmd.timeout = opts.timeout
+
+	method := opts.method
+	if method == "" && opts.head {
+		method = http.MethodHead
+	}
+	switch {
+	case len(opts.data) != 0 && opts.get:
+		q := cmd.Req.URL.RawQuery
+		if q != "" {
+			q += "&"
+		}
+		cmd.Req.URL.RawQuery = q + strings.Join(opts.data, "&")
+	case len(opts.data) != 0:
+		if method == "" {
+			method = http.MethodPost
+		}
+		contentType := "application/x-www-form-urlencoded"
+		if opts.json {
+			contentType = "application/json"
+			if cmd.Req.Header.Get("Accept") == "" {
+				cmd.Req.Header.Set("Accept", "application/json")
+			}
+		}
+		if cmd.Req.Header.Get("Content-Type") == "" {
+			cmd.Req.Header.Set("Content-Type", contentType)
+		}
+		cmd.Method(method, []byte(strings.Join(opts.data, "&")))
+	case len(opts.form) != 0:
+		if method != "" {
+			cmd.Req.Method = method
+		}
+		cmd.Multipart()
+		for _, p := range opts.form {
+			if p.file != nil {
+				p.file.scope = cmd.scope
+			}
+			cmd.parts.parts = append(cmd.parts.parts, p)
+		}
+	}
+	if method != "" {
+		cmd.Req.Method = method
+	}
+}
+
+// curlURLEncode a --data-urlencode value, "content", "=content" or "name=content"
+func curlURLEncode(value string) string {
+	i := strings.Index(value, "=")
+	switch {
+	case i < 0:
+		return url.QueryEscape(value)
+	case i == 0:
+		return url.QueryEscape(value[1:])
+	default:
+		return value[:i] + "=" + url.QueryEscape(value[i+1:])
+	}
+}
+
+// shellQuote a value so splitArgs (or a shell) reads it back unchanged
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@,+%", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,114 @@
+package lash_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_as_curl(t *testing.T) {
+	t.Run("method, headers, body and url are included", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		actual := scope.
+			Curl("https://example.com/items?q=a b").
+			PostJSON(map[string]string{"name": "it's"}).
+			Header("X-Trace", "abc").
+			AsCurl()
+
+		assert.Equal(t, `curl -X POST -H 'Accept: application/json' -H 'Content-Type: application/json' -H 'X-Trace: abc' `+
+			`--data-raw '{"name":"it'\''s"}' 'https://example.com/items?q=a b'`, actual)
+	})
+	t.Run("multipart uses form options", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		actual := scope.
+			Curl("https://example.com/upload").
+			Field("name", "@not-a-file").
+			FileField("doc", scope.OpenFile("/tmp/report.txt")).
+			AsCurl()
+
+		assert.Equal(t, `curl -X POST --form-string name=@not-a-file -F doc=@/tmp/report.txt https://example.com/upload`, actual)
+	})
+	t.Run("the command can be read back", func(t *testing.T) {
+		var method, header, body string
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			header = r.Header.Get("X-Quote")
+			b, _ := ioutil.ReadAll(r.Body)
+			body = string(b)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		line := scope.Curl(ts.URL).Put([]byte("a 'quoted' body")).Header("X-Quote", `"both" 'kinds'`).AsCurl()
+		scope.FromCurl(line).Response()
+
+		assert.Equal(t, http.MethodPut, method)
+		assert.Equal(t, `"both" 'kinds'`, header)
+		assert.Equal(t, "a 'quoted' body", body)
+	})
+}
+
+func Test_from_curl(t *testing.T) {
+	t.Run("browser style command", func(t *testing.T) {
+		var r *http.Request
+		var body string
+		ts := makeTestServer(func(w http.ResponseWriter, req *http.Request) {
+			r = req
+			b, _ := ioutil.ReadAll(req.Body)
+			body = string(b)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.FromCurl(`curl '` + ts.URL + `/api?x=1' \
+  -H 'accept: application/json' \
+  -H 'user-agent: Mozilla/5.0' \
+  -u user:pass \
+  --data-raw 'a=1&b=2' \
+  --compressed -sSL`).Response()
+
+		require.NotNil(t, r)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("x"))
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		assert.Equal(t, "Mozilla/5.0", r.Header.Get("User-Agent"))
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "user:pass", user+":"+pass)
+		assert.Equal(t, "a=1&b=2", body)
+	})
+	t.Run("short options with attached values", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		cmd := scope.FromCurl(`curl -XDELETE -H'X-Id: 7' https://example.com/items/7`)
+
+		assert.Equal(t, http.MethodDelete, cmd.Req.Method)
+		assert.Equal(t, "7", cmd.Req.Header.Get("X-Id"))
+	})
+	t.Run("get with data uses the query string", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		cmd := scope.FromCurl(`curl -G https://example.com/search -d q=go --data-urlencode 'name=a b'`)
+
+		assert.Equal(t, http.MethodGet, cmd.Req.Method)
+		assert.Equal(t, "q=go&name=a+b", cmd.Req.URL.RawQuery)
+	})
+	t.Run("invalid commands are an error", func(t *testing.T) {
+		for _, line := range []string{`wget https://example.com`, `curl -H 'unterminated`, `curl --unknown https://example.com`, `curl -s`} {
+			var actualErr error
+			scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+
+			scope.FromCurl(line)
+
+			require.Error(t, actualErr, line)
+			assert.Equal(t, "FromCurl", actualErr.(*lash.ScopeErr).Action, line)
+		}
+	})
+}
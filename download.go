@@ -0,0 +1,438 @@
+package lash
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+type (
+	// Download a url to a file, resuming from a previous attempt if possible
+	Download struct {
+		cmd      *HTTPRequest
+		dest     *File
+		workers  int
+		hash     func() hash.Hash
+		hashName string
+		expected string
+		progress ProgressFunc
+		// ETag or Last-Modified the parts were fetched with, see validator
+		validator string
+	}
+	// downloadChunk is a byte range, end is inclusive and -1 for the end of the file
+	downloadChunk struct {
+		start, end int64
+		path       string
+	}
+	// downloadProgress is shared by the chunks of a download
+	downloadProgress struct {
+		mu       sync.Mutex
+		written  int64
+		total    int64
+		progress ProgressFunc
+	}
+	// chunkWriter adds to the progress of the download
+	chunkWriter struct {
+		w        io.Writer
+		progress *downloadProgress
+	}
+)
+
+// Download url (which supports EnvStr) to dest, see HTTPRequest.Download
+func (s *Scope) Download(url string, dest *File) *Download {
+	return s.Curl(url).Download(dest)
+}
+
+// Download the response to dest when Run is called, the headers, auth, retries etc. of this request
+// are used for every request of the download. The body is written to dest.part and a failed
+// download is resumed from there with a Range request the next time it is run. The ETag (or Last-Modified)
+// is kept in dest.part.validator and sent as If-Range so a file that has changed is fetched again
+// rather than joined to the old part. When complete (and the checksum matches) the part file is renamed to dest
+func (cmd *HTTPRequest) Download(dest *File) *Download {
+	return &Download{cmd: cmd, dest: dest, workers: 1}
+}
+
+// Parallel fetches the file as this many chunks at once, if the server does not support
+// Range requests the file is fetched in one piece
+func (d *Download) Parallel(workers int) *Download {
+	d.workers = workers
+	return d
+}
+
+// SHA256 the file must have, as hex
+func (d *Download) SHA256(sum string) *Download {
+	d.hash, d.hashName, d.expected = sha256.New, "sha256", strings.ToLower(sum)
+	return d
+}
+
+// MD5 the file must have, as hex
+func (d *Download) MD5(sum string) *Download {
+	d.hash, d.hashName, d.expected = md5.New, "md5", strings.ToLower(sum)
+	return d
+}
+
+// Progress is called as the file is written, written includes any part from a previous attempt.
+// Calls are never concurrent
+func (d *Download) Progress(fn ProgressFunc) *Download {
+	d.progress = fn
+	return d
+}
+
+// Run the download, dest is returned
+func (d *Download) Run() *File {
+	if d.cmd.Req == nil {
+		return d.dest
+	}
+	if d.cmd.scope.dryRunf("download '%s' to '%s'", d.cmd.Req.URL, d.dest.path) {
+		return d.dest
+	}
+
+	progress := &downloadProgress{total: -1, progress: d.progress}
+	chunks := []*downloadChunk{{start: 0, end: -1, path: d.dest.path + ".part"}}
+	d.validator = readValidator(d.validatorPath())
+	if d.workers > 1 {
+		if total, v, ok := d.size(); ok && total >= int64(d.workers) {
+			if v != d.validator {
+				// the parts are of a different version of the file
+				d.removeParts()
+				d.validator = v
+				d.saveValidator(v)
+			}
+			progress.total = total
+			chunks = d.chunks(total)
+		}
+	}
+	for _, c := range chunks {
+		progress.written += fileSize(c.path)
+	}
+
+	var wg sync.WaitGroup
+	failed := make([]bool, len(chunks))
+	for i, c := range chunks {
+		wg.Add(1)
+		go func(i int, c *downloadChunk) {
+			defer wg.Done()
+			failed[i] = !d.fetch(c, progress)
+		}(i, c)
+	}
+	wg.Wait()
+	for _, f := range failed {
+		if f {
+			// the parts are kept so the next Run resumes
+			return d.dest
+		}
+	}
+
+	if err := d.finish(chunks); err != nil {
+		d.cmd.scope.setErr("Download", "Finish", err)
+	}
+	return d.dest
+}
+
+// size and validator of the file from a request for the first byte, false if Range is not supported
+func (d *Download) size() (int64, string, bool) {
+	probe := d.request()
+	probe.Req.Header.Set("Range", "bytes=0-0")
+	var total int64 = -1
+	var v string
+	probe.stream("Download", func(ctx context.Context, r *HTTPResponse) error {
+		if r.response.StatusCode == http.StatusPartialContent {
+			_, total = contentRange(r.response)
+			v = validator(r.response)
+		}
+		return nil
+	})
+	return total, v, total > 0
+}
+
+// chunks of total bytes, a part file from a different layout can not be resumed so it is removed
+func (d *Download) chunks(total int64) []*downloadChunk {
+	size := (total + int64(d.workers) - 1) / int64(d.workers)
+	var chunks []*downloadChunk
+	keep := map[string]bool{}
+	for start := int64(0); start < total; start += size {
+		end := start + size - 1
+		if end >= total {
+			end = total - 1
+		}
+		c := &downloadChunk{start: start, end: end, path: fmt.Sprintf("%s.part-%d-%d", d.dest.path, start, end)}
+		chunks = append(chunks, c)
+		keep[c.path] = true
+	}
+	stale, _ := filepath.Glob(d.dest.path + ".part-*")
+	for _, path := range stale {
+		if !keep[path] {
+			_ = os.Remove(path)
+		}
+	}
+	return chunks
+}
+
+// fetch the rest of a chunk, false if it could not be completed
+func (d *Download) fetch(c *downloadChunk, progress *downloadProgress) bool {
+	have := fileSize(c.path)
+	if c.end >= 0 {
+		length := c.end - c.start + 1
+		if have == length {
+			return true
+		}
+		if have > length {
+			_ = os.Remove(c.path)
+			progress.add(-have)
+			have = 0
+		}
+	}
+
+	req := d.request()
+	if have > 0 || c.end >= 0 {
+		end := ""
+		if c.end >= 0 {
+			end = strconv.FormatInt(c.end, 10)
+		}
+		req.Req.Header.Set("Range", fmt.Sprintf("bytes=%d-%s", c.start+have, end))
+		if d.validator != "" {
+			// a changed file is sent whole rather than the rest of it
+			req.Req.Header.Set("If-Range", d.validator)
+		}
+	}
+	done := false
+	req.stream("Download", func(ctx context.Context, r *HTTPResponse) error {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		switch r.response.StatusCode {
+		case http.StatusRequestedRangeNotSatisfiable:
+			// asking for the bytes after a complete part
+			if _, total := contentRange(r.response); c.end < 0 && have > 0 && total == have {
+				done = true
+				return nil
+			}
+			return xerrors.Errorf("range not satisfiable, remove '%s' to start again", c.path)
+		case http.StatusPartialContent:
+			start, total := contentRange(r.response)
+			if start != c.start+have {
+				return xerrors.Errorf("expected range from %d, got %d", c.start+have, start)
+			}
+			if v := validator(r.response); d.validator != "" && v != "" && v != d.validator {
+				// If-Range was ignored, the part is of a different version of the file
+				_ = os.Remove(c.path)
+				progress.add(-have)
+				return xerrors.Errorf("the file has changed, removed '%s' to start again", c.path)
+			}
+			if c.end < 0 {
+				progress.setTotal(total)
+			}
+		default:
+			// the whole file, the server does not support Range
+			if c.start != 0 || c.end >= 0 {
+				return xerrors.Errorf("status %d, expected %d for a range", r.response.StatusCode, http.StatusPartialContent)
+			}
+			flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+			progress.add(-have)
+			progress.setTotal(r.response.ContentLength)
+		}
+
+		if c.end < 0 {
+			if v := validator(r.response); v != d.validator {
+				d.validator = v
+				d.saveValidator(v)
+			}
+		}
+		out, err := os.OpenFile(c.path, flags, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(&chunkWriter{w: out, progress: progress}, r.response.Body)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		done = err == nil
+		return err
+	})
+	return done
+}
+
+// request for part of the download
+func (d *Download) request() *HTTPRequest {
+	req := d.cmd.withURL(d.cmd.Req.URL)
+	req.statuses = append(append([]int(nil), d.cmd.statuses...), http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable)
+	return req
+}
+
+// finish checks the sum and replaces dest with the complete file
+func (d *Download) finish(chunks []*downloadChunk) error {
+	var h hash.Hash
+	if d.hash != nil {
+		h = d.hash()
+	}
+	path := chunks[0].path
+	if len(chunks) > 1 {
+		var err error
+		if path, err = joinChunks(d.dest.path, chunks, h); err != nil {
+			return err
+		}
+	} else if h != nil {
+		if err := appendFile(h, path); err != nil {
+			return err
+		}
+	}
+
+	if h != nil {
+		if actual := hex.EncodeToString(h.Sum(nil)); actual != d.expected {
+			// a corrupt file would be resumed so start again next time
+			_ = os.Remove(path)
+			for _, c := range chunks {
+				_ = os.Remove(c.path)
+			}
+			_ = os.Remove(d.validatorPath())
+			return xerrors.Errorf("expected %s %s, got %s", d.hashName, d.expected, actual)
+		}
+	}
+	if err := os.Rename(path, d.dest.path); err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+	if len(chunks) > 1 {
+		for _, c := range chunks {
+			_ = os.Remove(c.path)
+		}
+	}
+	_ = os.Remove(d.validatorPath())
+	return nil
+}
+
+func (d *Download) validatorPath() string {
+	return d.dest.path + ".part.validator"
+}
+
+func (d *Download) saveValidator(v string) {
+	if v == "" {
+		_ = os.Remove(d.validatorPath())
+		return
+	}
+	_ = ioutil.WriteFile(d.validatorPath(), []byte(v), 0644)
+}
+
+// removeParts from previous runs
+func (d *Download) removeParts() {
+	_ = os.Remove(d.dest.path + ".part")
+	parts, _ := filepath.Glob(d.dest.path + ".part-*")
+	for _, path := range parts {
+		_ = os.Remove(path)
+	}
+}
+
+// validator of the response for If-Range, a strong ETag or else Last-Modified
+func validator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// readValidator saved by a previous run, empty if there is none
+func readValidator(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// joinChunks into a temporary file next to dest, writing to h as well
+func joinChunks(dest string, chunks []*downloadChunk, h hash.Hash) (string, error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), "")
+	if err != nil {
+		return "", err
+	}
+	var w io.Writer = tmp
+	if h != nil {
+		w = io.MultiWriter(tmp, h)
+	}
+	for _, c := range chunks {
+		if err = appendFile(w, c.path); err != nil {
+			break
+		}
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func appendFile(w io.Writer, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	_, err = io.Copy(w, in)
+	return err
+}
+
+// contentRange start and total, -1 if not known
+func contentRange(resp *http.Response) (int64, int64) {
+	// bytes 0-99/1234 or bytes */1234
+	v := strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	start, total := int64(-1), int64(-1)
+	i := strings.Index(v, "/")
+	if i < 0 {
+		return start, total
+	}
+	if n, err := strconv.ParseInt(v[i+1:], 10, 64); err == nil {
+		total = n
+	}
+	if j := strings.Index(v[:i], "-"); j > 0 {
+		if n, err := strconv.ParseInt(v[:j], 10, 64); err == nil {
+			start = n
+		}
+	}
+	return start, total
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (p *downloadProgress) add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.written += n
+	if p.progress != nil && n > 0 {
+		p.progress(p.written, p.total)
+	}
+}
+
+func (p *downloadProgress) setTotal(total int64) {
+	p.mu.Lock()
+	p.total = total
+	p.mu.Unlock()
+}
+
+func (w *chunkWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.progress.add(int64(n))
+	return n, err
+}
@@ -0,0 +1,212 @@
+package lash_test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeFileServer serves content with Range support, every Range header is recorded
+func makeFileServer(content string) (*rangeRecorder, func()) {
+	rec := &rangeRecorder{}
+	ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+		rec.mu.Lock()
+		rec.ranges = append(rec.ranges, r.Header.Get("Range"))
+		rec.mu.Unlock()
+		http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+	})
+	rec.url = ts.URL
+	return rec, ts.Close
+}
+
+type rangeRecorder struct {
+	url    string
+	mu     sync.Mutex
+	ranges []string
+}
+
+func Test_download(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+	sum := sha256.Sum256([]byte(content))
+	sha := hex.EncodeToString(sum[:])
+
+	t.Run("the file is checked and renamed into place", func(t *testing.T) {
+		server, done := makeFileServer(content)
+		defer done()
+		dest := tempPathname()
+		defer func() { _ = os.Remove(dest) }()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		var written, total int64
+		scope.Download(server.url, scope.OpenFile(dest)).
+			SHA256(strings.ToUpper(sha)).
+			Progress(func(w, t int64) { written, total = w, t }).
+			Run()
+
+		actual, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+		assert.Equal(t, int64(1000), written)
+		assert.Equal(t, int64(1000), total)
+		assertFileNotExists(t, dest+".part")
+	})
+	t.Run("a part file is resumed", func(t *testing.T) {
+		server, done := makeFileServer(content)
+		defer done()
+		dest := tempPathname()
+		defer func() { _ = os.Remove(dest) }()
+		defer writeFile(t, dest+".part", content[:300])()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		md5Sum := md5.Sum([]byte(content))
+		scope.Curl(server.url).Header("X-Any", "value").Download(scope.OpenFile(dest)).MD5(hex.EncodeToString(md5Sum[:])).Run()
+
+		actual, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+		assert.Equal(t, []string{"bytes=300-"}, server.ranges)
+	})
+	t.Run("a complete part file is finished", func(t *testing.T) {
+		server, done := makeFileServer(content)
+		defer done()
+		dest := tempPathname()
+		defer func() { _ = os.Remove(dest) }()
+		defer writeFile(t, dest+".part", content)()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Download(server.url, scope.OpenFile(dest)).SHA256(sha).Run()
+
+		actual, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+	})
+	t.Run("chunks are fetched in parallel", func(t *testing.T) {
+		server, done := makeFileServer(content)
+		defer done()
+		dest := tempPathname()
+		defer func() { _ = os.Remove(dest) }()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Download(server.url, scope.OpenFile(dest)).Parallel(4).SHA256(sha).Run()
+
+		actual, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+		assert.ElementsMatch(t, []string{"bytes=0-0", "bytes=0-249", "bytes=250-499", "bytes=500-749", "bytes=750-999"}, server.ranges)
+		parts, _ := filepath.Glob(dest + ".part*")
+		assert.Empty(t, parts)
+	})
+	t.Run("a server without range support starts again", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(content))
+		})
+		defer ts.Close()
+		dest := tempPathname()
+		defer func() { _ = os.Remove(dest) }()
+		defer writeFile(t, dest+".part", "stale")()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Download(ts.URL, scope.OpenFile(dest)).Parallel(4).Run()
+
+		actual, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+	})
+	t.Run("a checksum mismatch is an error", func(t *testing.T) {
+		server, done := makeFileServer(content)
+		defer done()
+		dest := tempPathname()
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+
+		scope.Download(server.url, scope.OpenFile(dest)).SHA256("00").Run()
+
+		require.Error(t, actualErr)
+		serr, ok := actualErr.(*lash.ScopeErr)
+		require.True(t, ok)
+		assert.Equal(t, "Download", serr.Type)
+		assert.Contains(t, serr.Error(), "expected sha256 00, got "+sha)
+		assertFileNotExists(t, dest)
+		assertFileNotExists(t, dest+".part")
+	})
+	t.Run("a part of a file that has changed is not resumed", func(t *testing.T) {
+		var ifRanges []string
+		var mu sync.Mutex
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			ifRanges = append(ifRanges, r.Header.Get("If-Range"))
+			mu.Unlock()
+			w.Header().Set("ETag", `"v2"`)
+			http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+		})
+		defer ts.Close()
+		dest := tempPathname()
+		defer func() { _ = os.Remove(dest) }()
+		defer writeFile(t, dest+".part", strings.Repeat("x", 300))()
+		defer writeFile(t, dest+".part.validator", `"v1"`)()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Download(ts.URL, scope.OpenFile(dest)).Run()
+
+		actual, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+		assert.Equal(t, []string{`"v1"`}, ifRanges)
+		assertFileNotExists(t, dest+".part.validator")
+	})
+	t.Run("a part of the same file is resumed", func(t *testing.T) {
+		var ifRanges []string
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			ifRanges = append(ifRanges, r.Header.Get("If-Range"))
+			w.Header().Set("ETag", `"v1"`)
+			http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+		})
+		defer ts.Close()
+		dest := tempPathname()
+		defer func() { _ = os.Remove(dest) }()
+		defer writeFile(t, dest+".part", content[:300])()
+		defer writeFile(t, dest+".part.validator", `"v1"`)()
+		var written int64
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Download(ts.URL, scope.OpenFile(dest)).Progress(func(w, t int64) { written = w }).Run()
+
+		actual, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+		assert.Equal(t, []string{`"v1"`}, ifRanges)
+		assert.Equal(t, int64(1000), written)
+	})
+	t.Run("parallel parts of a file that has changed are discarded", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v2"`)
+			http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+		})
+		defer ts.Close()
+		dest := tempPathname()
+		defer func() { _ = os.Remove(dest) }()
+		defer writeFile(t, dest+".part-0-249", strings.Repeat("x", 250))()
+		defer writeFile(t, dest+".part.validator", `"v1"`)()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Download(ts.URL, scope.OpenFile(dest)).Parallel(4).Run()
+
+		actual, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+		parts, _ := filepath.Glob(dest + ".part*")
+		assert.Empty(t, parts)
+	})
+}
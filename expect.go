@@ -0,0 +1,105 @@
+package lash
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// ExpectStatus is one of the statuses
+func (r *HTTPResponse) ExpectStatus(status ...int) *HTTPResponse {
+	for _, s := range status {
+		if r.StatusCode() == s {
+			return r
+		}
+	}
+	return r.expectFailed("ExpectStatus", "status %v, got %d", status, r.StatusCode())
+}
+
+// ExpectHeader has the value, the value supports EnvStr
+func (r *HTTPResponse) ExpectHeader(name, value string, args ...interface{}) *HTTPResponse {
+	value = r.scope.EnvStr(value, args...)
+	if actual := r.Header(name); actual != value {
+		return r.expectFailed("ExpectHeader", "header '%s' to be %q, got %q", name, value, actual)
+	}
+	return r
+}
+
+// ExpectJSON the value at the path of the json body (see JSON.Get) equals value, numbers
+// of any type are compared by value so ExpectJSON("count", 3) works
+func (r *HTTPResponse) ExpectJSON(path string, value interface{}) *HTTPResponse {
+	body, err := decodeJSON(r.BodyBytes())
+	if err != nil {
+		return r.expectFailed("ExpectJSON", "a json body: %v", err)
+	}
+	actual, found := (&JSON{value: body, ok: true}).lookup(path)
+	if !found {
+		return r.expectFailed("ExpectJSON", "path '%s' to be %s, got nothing", path, compact(value))
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return r.expectFailed("ExpectJSON", "a json value for path '%s': %v", path, err)
+	}
+	expected, _ := decodeJSON(b)
+	if !reflect.DeepEqual(normalise(expected), normalise(actual)) {
+		return r.expectFailed("ExpectJSON", "path '%s' to be %s, got %s", path, string(b), compact(actual))
+	}
+	return r
+}
+
+// ExpectBodyContains the text, the text supports EnvStr
+func (r *HTTPResponse) ExpectBodyContains(text string, args ...interface{}) *HTTPResponse {
+	text = r.scope.EnvStr(text, args...)
+	if !strings.Contains(r.BodyString(), text) {
+		return r.expectFailed("ExpectBodyContains", "body to contain %q, got %q", text, abbreviate(r.BodyString(), 200))
+	}
+	return r
+}
+
+// ExpectMaxLatency the request took no longer than d, see Duration
+func (r *HTTPResponse) ExpectMaxLatency(d time.Duration) *HTTPResponse {
+	if r.Duration() > d {
+		return r.expectFailed("ExpectMaxLatency", "at most %v, got %v", d, r.Duration())
+	}
+	return r
+}
+
+// ExpectSchema the json body is valid for the JSON Schema in the file
+func (r *HTTPResponse) ExpectSchema(f *File) *HTTPResponse {
+	// not File.String which reads nothing once the scope has an error, e.g. an earlier expectation
+	content, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		r.scope.setErr("HTTPResponse", "ExpectSchema", xerrors.Errorf("path '%s': %w", f.path, err))
+		return r
+	}
+	root, err := decodeJSON(content)
+	m, ok := root.(map[string]interface{})
+	if err != nil || !ok {
+		return r.expectFailed("ExpectSchema", "schema '%s' to be a json object: %v", f.path, err)
+	}
+	body, err := decodeJSON(r.BodyBytes())
+	if err != nil {
+		return r.expectFailed("ExpectSchema", "a json body: %v", err)
+	}
+	if errs := (schema{root: m}).validate(body); len(errs) != 0 {
+		return r.expectFailed("ExpectSchema", "body to match '%s', got %s", f.path, strings.Join(errs, "; "))
+	}
+	return r
+}
+
+func (r *HTTPResponse) expectFailed(action, format string, args ...interface{}) *HTTPResponse {
+	r.scope.SetErr(&ScopeErr{Type: "HTTPResponse", Action: action, Err: fmt.Errorf("expected "+format, args...)})
+	return r
+}
+
+func abbreviate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
@@ -0,0 +1,148 @@
+package lash_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const smokeSchema = `{
+  "type": "object",
+  "required": ["id", "items"],
+  "properties": {
+    "id": {"type": "integer", "minimum": 1},
+    "status": {"enum": ["ok", "degraded"]},
+    "items": {"type": "array", "items": {"$ref": "#/definitions/item"}}
+  },
+  "definitions": {
+    "item": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string", "minLength": 1}}}
+  }
+}`
+
+func Test_response_expectations(t *testing.T) {
+	body := `{"id":7,"status":"ok","items":[{"name":"a"},{"name":"b"}],"nested":{"list":[1,2]}}`
+	ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	})
+	defer ts.Close()
+	schemaFile := tempPathname()
+	defer writeFile(t, schemaFile, smokeSchema)()
+
+	t.Run("passing expectations are not errors", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Curl(ts.URL).Response().
+			ExpectStatus(200).
+			ExpectHeader("Content-Type", "application/json").
+			ExpectJSON("id", 7).
+			ExpectJSON("items.1.name", "b").
+			ExpectJSON("nested", map[string]interface{}{"list": []int{1, 2}}).
+			ExpectBodyContains(`"status":"ok"`).
+			ExpectMaxLatency(time.Minute).
+			ExpectSchema(scope.OpenFile(schemaFile))
+	})
+	failures := []struct {
+		name     string
+		expect   func(*lash.HTTPResponse)
+		expected string
+	}{
+		{"status", func(r *lash.HTTPResponse) { r.ExpectStatus(201, 204) },
+			"HTTPResponse:ExpectStatus:expected status [201 204], got 200"},
+		{"header", func(r *lash.HTTPResponse) { r.ExpectHeader("Content-Type", "text/plain") },
+			`HTTPResponse:ExpectHeader:expected header 'Content-Type' to be "text/plain", got "application/json"`},
+		{"json value", func(r *lash.HTTPResponse) { r.ExpectJSON("items.0.name", "z") },
+			`HTTPResponse:ExpectJSON:expected path 'items.0.name' to be "z", got "a"`},
+		{"json path", func(r *lash.HTTPResponse) { r.ExpectJSON("missing", 1) },
+			`HTTPResponse:ExpectJSON:expected path 'missing' to be 1, got nothing`},
+		{"body", func(r *lash.HTTPResponse) { r.ExpectBodyContains("degraded") },
+			`HTTPResponse:ExpectBodyContains:expected body to contain "degraded", got "{`},
+		{"latency", func(r *lash.HTTPResponse) { r.ExpectMaxLatency(time.Nanosecond) },
+			"HTTPResponse:ExpectMaxLatency:expected at most 1ns, got"},
+	}
+	for _, f := range failures {
+		t.Run("failing "+f.name, func(t *testing.T) {
+			scope := lash.NewScope().OnError(lash.Ignore)
+
+			f.expect(scope.Curl(ts.URL).Response())
+
+			require.Error(t, scope.Err())
+			assert.Contains(t, scope.Err().Error(), f.expected)
+		})
+	}
+	t.Run("failing schema", func(t *testing.T) {
+		other := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"id":0,"status":"down","items":[{"name":""},{}]}`))
+		})
+		defer other.Close()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.Curl(other.URL).Response().ExpectSchema(scope.OpenFile(schemaFile))
+
+		require.Error(t, scope.Err())
+		msg := scope.Err().Error()
+		assert.Contains(t, msg, "HTTPResponse:ExpectSchema:expected body to match")
+		assert.Contains(t, msg, "$.id: expected minimum 1, got 0")
+		assert.Contains(t, msg, `$.status: "down" is not one of ["ok","degraded"]`)
+		assert.Contains(t, msg, "$.items.0.name: expected length at least 1, got 0")
+		assert.Contains(t, msg, "$.items.1: required property 'name' is missing")
+	})
+	t.Run("schema is checked after an earlier expectation has failed", func(t *testing.T) {
+		other := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"id":0,"status":"ok","items":[]}`))
+		})
+		defer other.Close()
+		var errs []error
+		scope := lash.NewScope().OnError(func(err error) { errs = append(errs, err) })
+
+		scope.Curl(other.URL).Response().
+			ExpectHeader("X-Missing", "value").
+			ExpectSchema(scope.OpenFile(schemaFile))
+
+		require.Len(t, errs, 2)
+		assert.Contains(t, errs[1].Error(), "$.id: expected minimum 1, got 0")
+	})
+	t.Run("a missing schema file is an error", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.Curl(ts.URL).Response().ExpectSchema(scope.OpenFile(tempPathname()))
+
+		require.Error(t, scope.Err())
+		assert.Contains(t, scope.Err().Error(), "HTTPResponse:ExpectSchema:path")
+	})
+	t.Run("schema with a $ref cycle is an error", func(t *testing.T) {
+		schemas := []string{
+			`{"$ref":"#"}`,
+			`{"definitions":{"a":{"$ref":"#/definitions/b"},"b":{"$ref":"#/definitions/a"}},"$ref":"#/definitions/a"}`,
+		}
+		for _, schema := range schemas {
+			cyclic := tempPathname()
+			cleanup := writeFile(t, cyclic, schema)
+			scope := lash.NewScope().OnError(lash.Ignore)
+
+			scope.Curl(ts.URL).Response().ExpectSchema(scope.OpenFile(cyclic))
+
+			cleanup()
+			require.Error(t, scope.Err())
+			assert.Contains(t, scope.Err().Error(), "is a cycle")
+		}
+	})
+	t.Run("recursive schema for nested data is not a cycle", func(t *testing.T) {
+		tree := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"name":"a","children":[{"name":"b","children":[]}]}`))
+		})
+		defer tree.Close()
+		recursive := tempPathname()
+		defer writeFile(t, recursive, `{"type":"object","required":["name"],
+			"properties":{"children":{"type":"array","items":{"$ref":"#"}}}}`)()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.Curl(tree.URL).Response().ExpectSchema(scope.OpenFile(recursive))
+
+		assert.NoError(t, scope.Err())
+	})
+}
@@ -2,6 +2,7 @@ package lash
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/xerrors"
 )
@@ -24,6 +26,9 @@ type (
 	fileAppender struct {
 		file *File
 		wg   *sync.WaitGroup
+		ctx  context.Context
+		// lines not written because the scope was cancelled
+		dropped *int32
 	}
 	//FileAppender for concurrently appending to a file
 	FileAppender interface {
@@ -43,7 +48,7 @@ func (s *Scope) OpenFile(name string, args ...interface{}) *File {
 
 // String content
 func (f *File) String() string {
-	if f.scope.err != nil {
+	if f.scope.IsError() {
 		return ""
 	}
 	b, err := ioutil.ReadFile(f.path)
@@ -68,8 +73,10 @@ func (f *File) AsJSON(v interface{}) {
 }
 
 // ReadLines read all lines via a channel one line at a lime
+// the channel is closed early if the scope is cancelled
 func (f *File) ReadLines() chan string {
 	ch := make(chan string)
+	ctx := f.scope.Context()
 
 	go func() {
 		defer close(ch)
@@ -85,7 +92,12 @@ func (f *File) ReadLines() chan string {
 		scanner.Split(bufio.ScanLines)
 
 		for scanner.Scan() {
-			ch <- scanner.Text()
+			select {
+			case ch <- scanner.Text():
+			case <-ctx.Done():
+				f.scope.setErr("File", "ReadLines", ctx.Err())
+				return
+			}
 		}
 	}()
 
@@ -93,7 +105,10 @@ func (f *File) ReadLines() chan string {
 }
 
 func (f *File) AppendLine(s string, args ...interface{}) *File {
-	if f.scope != nil && f.scope.err != nil {
+	if f.scope.IsError() {
+		return f
+	}
+	if f.scope.dryRunf("append to '%s': %s", f.path, f.scope.EnvStr(s, args...)) {
 		return f
 	}
 	f.open(openBasic)
@@ -107,6 +122,9 @@ func (f *File) AppendLine(s string, args ...interface{}) *File {
 
 //Truncate a file to zero length
 func (f *File) Truncate() *File {
+	if f.scope.dryRunf("truncate '%s'", f.path) {
+		return f
+	}
 	if f.isOpen() {
 		err := &ScopeErr{Type: "File", Action: "Truncate"}
 		// use underlying file close so we can set the correct error context
@@ -122,11 +140,16 @@ func (f *File) Appender() FileAppender {
 	if f.ch == nil {
 		f.ch = make(chan string)
 	}
-	a := fileAppender{file: f, wg: &sync.WaitGroup{}}
+	a := fileAppender{file: f, wg: &sync.WaitGroup{}, ctx: f.scope.Context(), dropped: new(int32)}
 	a.wg.Add(1)
 	go func() {
 		for line := range a.file.ch {
-			a.file.AppendLine(line)
+			// once cancelled keep receiving so senders are not blocked
+			if a.ctx.Err() == nil {
+				a.file.AppendLine(line)
+			} else {
+				atomic.AddInt32(a.dropped, 1)
+			}
 		}
 		a.wg.Done()
 	}()
@@ -166,6 +189,9 @@ func (f *File) open(flag openFlag) {
 
 // Delete the file
 func (f *File) Delete() {
+	if f.scope.dryRunf("delete '%s'", f.path) {
+		return
+	}
 	f.Close()
 	err := os.Remove(f.path)
 	f.scope.setErr("File", "Delete", err)
@@ -173,14 +199,19 @@ func (f *File) Delete() {
 
 // Mkdir creates the full path to ensure the supplied folder exists
 func (f *File) Mkdir() {
+	if f.scope.dryRunf("mkdir '%s'", f.path) {
+		return
+	}
 	err := os.MkdirAll(f.path, 0666)
 	f.scope.setErr("File", "Mkdir", err)
 }
 
 // CopyTo a destination, returns the destination
 func (f *File) CopyTo(dest string) *File {
-	err := copyFile(f.path, dest)
-	f.scope.setErr("File", "Copy", err)
+	if !f.scope.dryRunf("copy '%s' to '%s'", f.path, dest) {
+		err := copyFile(f.path, dest)
+		f.scope.setErr("File", "Copy", err)
+	}
 	return f.scope.OpenFile(dest)
 }
 
@@ -191,20 +222,28 @@ func (a fileAppender) Ch() chan<- string {
 
 // AppendLine wraps sending to the append channel, Ch
 func (a fileAppender) AppendLine(line string, args ...interface{}) {
-	a.file.ch <- a.file.scope.EnvStr(line, args...)
+	select {
+	case a.file.ch <- a.file.scope.EnvStr(line, args...):
+	case <-a.ctx.Done():
+		atomic.AddInt32(a.dropped, 1)
+	}
 }
 
-// Close the underlying channel and the target file
+// Close the underlying channel and the target file once pending lines have been written,
+// after the scope is cancelled lines are no longer written and that is an error
 func (a fileAppender) Close() {
+	if a.file == nil {
+		return
+	}
 	if a.file.ch != nil {
 		close(a.file.ch)
+		// only the line being written (if any) is waited for once cancelled
 		a.wg.Wait()
+		if atomic.LoadInt32(a.dropped) != 0 {
+			a.file.scope.setErr("File", "Appender", a.ctx.Err())
+		}
 	}
-
-	if a.file != nil {
-		a.file.Close()
-		a.file = nil
-	}
+	a.file.Close()
 }
 
 func copyFile(src, dst string) error {
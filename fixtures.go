@@ -0,0 +1,177 @@
+package lash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+)
+
+type (
+	// fixture is one line of a fixture file
+	fixture struct {
+		Method     string      `json:"method"`
+		URL        string      `json:"url"`
+		Status     int         `json:"status"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       string      `json:"body,omitempty"`
+		BodyBase64 []byte      `json:"body_base64,omitempty"`
+	}
+	// fixtures being recorded to or replayed from a file
+	fixtures struct {
+		mu     sync.Mutex
+		path   string
+		replay bool
+		// replayed responses by method and url, in the order they were recorded
+		entries map[string][]*fixture
+	}
+	fixtureTransport struct {
+		fixtures *fixtures
+		next     http.RoundTripper
+	}
+)
+
+// Record every http response for this scope (and child scopes) to a fixture file so it can be used with Replay,
+// the file is replaced. Each line is a json object with the method, url, status, headers and body
+func (s *Scope) Record(f *File) *Scope {
+	if s.dryRunf("record to '%s'", f.path) {
+		return s
+	}
+	if err := ioutil.WriteFile(f.path, nil, 0644); err != nil {
+		s.setErr("Scope", "Record", err)
+		return s
+	}
+	h := s.ownHTTP()
+	h.mu.Lock()
+	h.fixtures = &fixtures{path: f.path}
+	h.mu.Unlock()
+	return s
+}
+
+// Replay http responses from a fixture file made with Record instead of using the network.
+// Requests are matched by method and url, repeated requests get the recorded responses in order
+// (the last is repeated), a request with no fixture fails
+func (s *Scope) Replay(f *File) *Scope {
+	fx, err := loadFixtures(f.path)
+	if err != nil {
+		s.setErr("Scope", "Replay", err)
+		return s
+	}
+	h := s.ownHTTP()
+	h.mu.Lock()
+	h.fixtures = fx
+	h.mu.Unlock()
+	return s
+}
+
+func loadFixtures(path string) (*fixtures, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = in.Close() }()
+
+	fx := &fixtures{path: path, replay: true, entries: map[string][]*fixture{}}
+	scanner := newLineScanner(in)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var f fixture
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			return nil, xerrors.Errorf("'%s' line %d: %w", path, line, err)
+		}
+		key := f.Method + " " + f.URL
+		fx.entries[key] = append(fx.entries[key], &f)
+	}
+	return fx, scanner.Err()
+}
+
+// RoundTrip records or replays, replayed responses never reach the network
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.fixtures.replay {
+		return t.fixtures.response(req)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	f := &fixture{Method: req.Method, URL: req.URL.String(), Status: resp.StatusCode, Header: resp.Header}
+	if utf8.Valid(body) {
+		f.Body = string(body)
+	} else {
+		f.BodyBase64 = body
+	}
+	if err := t.fixtures.append(f); err != nil {
+		_ = resp.Body.Close()
+		return nil, xerrors.Errorf("record fixture: %w", err)
+	}
+	return resp, nil
+}
+
+func (fx *fixtures) append(f *fixture) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	fx.mu.Lock()
+	defer fx.mu.Unlock()
+	out, err := os.OpenFile(fx.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(b, '\n'))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (fx *fixtures) response(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	key := req.Method + " " + req.URL.String()
+	fx.mu.Lock()
+	entries := fx.entries[key]
+	if len(entries) > 1 {
+		fx.entries[key] = entries[1:]
+	}
+	fx.mu.Unlock()
+	if len(entries) == 0 {
+		return nil, xerrors.Errorf("no fixture for %s in '%s'", key, fx.path)
+	}
+
+	f := entries[0]
+	body := f.BodyBase64
+	if body == nil {
+		body = []byte(f.Body)
+	}
+	// a copy so the replayed fixture is not changed by the caller
+	header := cloneHeader(f.Header)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
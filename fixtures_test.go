@@ -0,0 +1,87 @@
+package lash_test
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_record_and_replay(t *testing.T) {
+	t.Run("recorded responses are replayed without the network", func(t *testing.T) {
+		filename := tempPathname()
+		defer func() { _ = os.Remove(filename) }()
+		count := 0
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			count++
+			w.Header().Set("X-Count", "yes")
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusCreated)
+			}
+			_, _ = w.Write([]byte{byte('0' + count), 0xff})
+		})
+		url := ts.URL
+
+		record := lash.NewScope().OnError(requireNoError(t))
+		record.Record(record.OpenFile(filename))
+		record.Curl(url + "/poll").Response()
+		record.Curl(url + "/poll").Response()
+		record.Child().Curl(url + "/items").Post([]byte("{}")).Response()
+		ts.Close()
+
+		replay := lash.NewScope().OnError(requireNoError(t))
+		replay.Replay(replay.OpenFile(filename))
+		first := replay.Curl(url + "/poll").Response()
+		second := replay.Curl(url + "/poll").Response()
+		again := replay.Curl(url + "/poll").Response()
+		created := replay.Curl(url + "/items").Post([]byte("{}")).Response()
+
+		assert.Equal(t, []byte{'1', 0xff}, first.BodyBytes())
+		assert.Equal(t, "yes", first.Header("X-Count"))
+		assert.Equal(t, []byte{'2', 0xff}, second.BodyBytes())
+		assert.Equal(t, []byte{'2', 0xff}, again.BodyBytes())
+		assert.Equal(t, http.StatusCreated, created.StatusCode())
+		assert.Equal(t, []byte{'3', 0xff}, created.BodyBytes())
+	})
+	t.Run("a request without a fixture fails", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, `{"method":"GET","url":"http://example.com/a","status":200,"body":"a"}`+"\n")()
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+
+		scope.Replay(scope.OpenFile(filename)).Curl("http://example.com/b").Response()
+
+		require.Error(t, actualErr)
+		assert.Contains(t, actualErr.Error(), "no fixture for GET http://example.com/b")
+	})
+	t.Run("a fixture that can not be recorded fails the request", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {})
+		defer ts.Close()
+		filename := tempPathname()
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+		scope.Record(scope.OpenFile(filename))
+		// appending to a directory fails
+		require.NoError(t, os.Remove(filename))
+		require.NoError(t, os.Mkdir(filename, 0755))
+		defer func() { _ = os.Remove(filename) }()
+
+		resp := scope.Curl(ts.URL).Response()
+
+		require.Error(t, actualErr)
+		assert.Contains(t, actualErr.Error(), "record fixture")
+		assert.Equal(t, 0, resp.StatusCode())
+	})
+	t.Run("a missing fixture file is an error", func(t *testing.T) {
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+
+		scope.Replay(scope.OpenFile(tempPathname()))
+
+		require.Error(t, actualErr)
+		assert.Equal(t, "Replay", actualErr.(*lash.ScopeErr).Action)
+	})
+}
@@ -0,0 +1,114 @@
+package lash
+
+import (
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+type (
+	multipartBody struct {
+		boundary string
+		parts    []multipartPart
+	}
+	multipartPart struct {
+		name  string
+		value string
+		file  *File
+	}
+)
+
+// Form sets the body as application/x-www-form-urlencoded, values support EnvStr.
+// A GET becomes a POST
+func (cmd *HTTPRequest) Form(values map[string]string) *HTTPRequest {
+	form := url.Values{}
+	for k, v := range values {
+		form.Set(k, cmd.scope.EnvStr(v))
+	}
+	cmd.Req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return cmd.Method(cmd.postUnlessSet(), []byte(form.Encode()))
+}
+
+// Multipart sets the body as multipart/form-data, add parts with Field and FileField.
+// A GET becomes a POST. The body is streamed when the request is sent so files are not
+// read into memory
+func (cmd *HTTPRequest) Multipart() *HTTPRequest {
+	mw := multipart.NewWriter(nil)
+	cmd.parts = &multipartBody{boundary: mw.Boundary()}
+	cmd.Req.Method = cmd.postUnlessSet()
+	cmd.Req.Header.Set("Content-Type", mw.FormDataContentType())
+	cmd.Req.Body = nil
+	cmd.Req.ContentLength = 0
+	cmd.Req.GetBody = cmd.parts.reader
+	return cmd
+}
+
+// Field adds a value to a Multipart body, the value supports EnvStr
+func (cmd *HTTPRequest) Field(name, value string, args ...interface{}) *HTTPRequest {
+	if cmd.parts == nil {
+		cmd.Multipart()
+	}
+	cmd.parts.parts = append(cmd.parts.parts, multipartPart{name: name, value: cmd.scope.EnvStr(value, args...)})
+	return cmd
+}
+
+// FileField adds the content of a file to a Multipart body
+func (cmd *HTTPRequest) FileField(name string, f *File) *HTTPRequest {
+	if cmd.parts == nil {
+		cmd.Multipart()
+	}
+	cmd.parts.parts = append(cmd.parts.parts, multipartPart{name: name, file: f})
+	return cmd
+}
+
+func (cmd *HTTPRequest) postUnlessSet() string {
+	if cmd.Req.Method == "" || cmd.Req.Method == http.MethodGet {
+		return http.MethodPost
+	}
+	return cmd.Req.Method
+}
+
+// reader streams the body, any error reading files is returned from Read
+func (m *multipartBody) reader() (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	if err := mw.SetBoundary(m.boundary); err != nil {
+		return nil, err
+	}
+	go func() {
+		err := m.write(mw)
+		if err == nil {
+			err = mw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+func (m *multipartBody) write(mw *multipart.Writer) error {
+	for _, p := range m.parts {
+		if p.file == nil {
+			if err := mw.WriteField(p.name, p.value); err != nil {
+				return err
+			}
+			continue
+		}
+		w, err := mw.CreateFormFile(p.name, filepath.Base(p.file.path))
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(p.file.path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, in)
+		_ = in.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package lash_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_form_uploads(t *testing.T) {
+	t.Run("url encoded form", func(t *testing.T) {
+		var method, name, other string
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			require.NoError(t, r.ParseForm())
+			name = r.PostForm.Get("name")
+			other = r.PostForm.Get("other")
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Curl(ts.URL).Form(map[string]string{"name": "a & b", "other": "x=y"}).Response()
+
+		assert.Equal(t, "POST", method)
+		assert.Equal(t, "a & b", name)
+		assert.Equal(t, "x=y", other)
+	})
+	t.Run("multipart with fields and files", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, "the file content")()
+		var field, content, uploadName string
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseMultipartForm(1024))
+			field = r.FormValue("field")
+			f, header, err := r.FormFile("upload")
+			require.NoError(t, err)
+			buf, _ := ioutil.ReadAll(f)
+			content = string(buf)
+			uploadName = header.Filename
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.
+			Curl(ts.URL).
+			Multipart().
+			Field("field", "value $0", 1).
+			FileField("upload", scope.OpenFile(filename)).
+			Response()
+
+		assert.Equal(t, "value 1", field)
+		assert.Equal(t, "the file content", content)
+		assert.Equal(t, filepath.Base(filename), uploadName)
+	})
+	t.Run("multipart body is sent again when retried", func(t *testing.T) {
+		var fields []string
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseMultipartForm(1024))
+			fields = append(fields, r.FormValue("field"))
+			if len(fields) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Curl(ts.URL).Field("field", "value").Retry(1).Backoff(time.Millisecond, time.Millisecond).Response()
+
+		assert.Equal(t, []string{"value", "value"}, fields)
+	})
+	t.Run("missing files are an error when sending", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = ioutil.ReadAll(r.Body)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.Curl(ts.URL).FileField("upload", scope.OpenFile("no-such-file")).Response()
+
+		require.Error(t, scope.Err())
+		assert.Contains(t, scope.Err().Error(), "no-such-file")
+	})
+}
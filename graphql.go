@@ -0,0 +1,189 @@
+package lash
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type (
+	// GraphQL endpoint, every query is a POST
+	GraphQL struct {
+		scope    *Scope
+		endpoint string
+		defaults []func(cmd *HTTPRequest)
+	}
+	// GraphQLResult of a query, the data is available even when there are errors
+	GraphQLResult struct {
+		scope    *Scope
+		response *HTTPResponse
+		data     json.RawMessage
+	}
+	graphQLError struct {
+		Message string        `json:"message"`
+		Path    []interface{} `json:"path"`
+	}
+	graphQLConnection struct {
+		Edges []struct {
+			Node json.RawMessage `json:"node"`
+		} `json:"edges"`
+		Nodes    []json.RawMessage `json:"nodes"`
+		PageInfo *struct {
+			HasNextPage bool    `json:"hasNextPage"`
+			EndCursor   *string `json:"endCursor"`
+		} `json:"pageInfo"`
+	}
+)
+
+// GraphQL endpoint for this scope, the endpoint supports EnvStr
+func (s *Scope) GraphQL(endpoint string, args ...interface{}) *GraphQL {
+	return &GraphQL{scope: s, endpoint: s.EnvStr(endpoint, args...)}
+}
+
+// Header for every query, this overwrites any previous value. The value supports EnvStr
+func (g *GraphQL) Header(name, value string, args ...interface{}) *GraphQL {
+	value = g.scope.EnvStr(value, args...)
+	return g.CommonFunc(func(cmd *HTTPRequest) {
+		cmd.Req.Header.Set(name, value)
+	})
+}
+
+// CommonFunc is called for every query, e.g. for auth
+func (g *GraphQL) CommonFunc(custom func(r *HTTPRequest)) *GraphQL {
+	if custom != nil {
+		g.defaults = append(g.defaults, custom)
+	}
+	return g
+}
+
+// Query (or mutation) with variables, which can be nil. A response with an errors array
+// is an error for the scope even when the status is 200
+func (g *GraphQL) Query(query string, vars map[string]interface{}) *GraphQLResult {
+	r := &GraphQLResult{scope: g.scope}
+	cmd := g.scope.newHTTPRequest("GraphQL", g.endpoint)
+	if cmd.Req == nil {
+		return r
+	}
+	cmd.PostJSON(map[string]interface{}{"query": query, "variables": vars})
+	for _, fn := range g.defaults {
+		fn(cmd)
+	}
+	r.response = cmd.Response()
+	if r.response.response == nil || !isInList(r.response.response.StatusCode, cmd.statuses) {
+		return r
+	}
+
+	var body struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	if err := json.Unmarshal(r.response.body, &body); err != nil {
+		g.scope.setErr("GraphQL", "Query", err)
+		return r
+	}
+	r.data = body.Data
+	if len(body.Errors) != 0 {
+		g.scope.setErr("GraphQL", "Query", graphQLErrors(body.Errors))
+	}
+	return r
+}
+
+// Paginate a connection using cursors, connection is the path of the connection
+// in the data as per JSON.Get e.g. "repository.issues". The query must take an $after variable and select
+// pageInfo { hasNextPage endCursor } with edges { node } or nodes. Each node is sent on the channel
+func (g *GraphQL) Paginate(query string, vars map[string]interface{}, connection string) chan json.RawMessage {
+	ch := make(chan json.RawMessage)
+	ctx := g.scope.Context()
+	page := map[string]interface{}{}
+	for k, v := range vars {
+		page[k] = v
+	}
+	go func() {
+		defer close(ch)
+		for {
+			result := g.Query(query, page)
+			if result.data == nil {
+				return
+			}
+			conn, err := result.connection(connection)
+			if err != nil {
+				g.scope.setErr("GraphQL", "Paginate", err)
+				return
+			}
+			nodes := conn.Nodes
+			for _, edge := range conn.Edges {
+				nodes = append(nodes, edge.Node)
+			}
+			for _, node := range nodes {
+				select {
+				case ch <- node:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if !conn.PageInfo.HasNextPage || conn.PageInfo.EndCursor == nil {
+				return
+			}
+			page["after"] = *conn.PageInfo.EndCursor
+		}
+	}()
+	return ch
+}
+
+// FromJSON decodes the data into buf, returns false if there is no data
+func (r *GraphQLResult) FromJSON(buf interface{}) bool {
+	if r == nil || len(r.data) == 0 || string(r.data) == "null" {
+		return false
+	}
+	if err := json.Unmarshal(r.data, buf); err != nil {
+		r.scope.setErr("GraphQL", "FromJSON", err)
+		return false
+	}
+	return true
+}
+
+// JSON of the data to query with paths
+func (r *GraphQLResult) JSON() *JSON {
+	return parseJSON(r.scope, r.data, "GraphQL")
+}
+
+// Response the data came from, nil if the request could not be made
+func (r *GraphQLResult) Response() *HTTPResponse {
+	return r.response
+}
+
+func (r *GraphQLResult) connection(path string) (*graphQLConnection, error) {
+	raw, err := jsonRaw(r.data, path)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, xerrors.Errorf("connection '%s' not found", path)
+	}
+	var conn graphQLConnection
+	if err := json.Unmarshal(raw, &conn); err != nil {
+		return nil, xerrors.Errorf("connection '%s': %w", path, err)
+	}
+	if conn.PageInfo == nil {
+		return nil, xerrors.Errorf("connection '%s' has no pageInfo", path)
+	}
+	return &conn, nil
+}
+
+// graphQLErrors as one error, each message with its path
+func graphQLErrors(errs []graphQLError) error {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Message
+		if len(e.Path) != 0 {
+			parts := make([]string, len(e.Path))
+			for j, p := range e.Path {
+				parts[j] = fmt.Sprint(p)
+			}
+			messages[i] += " (at " + strings.Join(parts, ".") + ")"
+		}
+	}
+	return xerrors.New(strings.Join(messages, "; "))
+}
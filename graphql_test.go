@@ -0,0 +1,102 @@
+package lash_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeGraphQLServer(t *testing.T, handler func(query string, vars map[string]interface{}) string) *httptest.Server {
+	return makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(handler(body.Query, body.Variables)))
+	})
+}
+
+func Test_graphql(t *testing.T) {
+	t.Run("data can be decoded", func(t *testing.T) {
+		var auth string
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("Authorization")
+			_, _ = w.Write([]byte(`{"data":{"user":{"name":"ada","id":"7"}}}`))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		result := scope.GraphQL(ts.URL).
+			Header("Authorization", "Bearer token").
+			Query(`query($id: ID!) { user(id: $id) { id name } }`, map[string]interface{}{"id": "7"})
+
+		var data struct {
+			User struct{ Name string }
+		}
+		require.True(t, result.FromJSON(&data))
+		assert.Equal(t, "ada", data.User.Name)
+		assert.Equal(t, "7", result.JSON().Get("user.id").String())
+		assert.Equal(t, "Bearer token", auth)
+	})
+	t.Run("errors with a 200 status are an error", func(t *testing.T) {
+		ts := makeGraphQLServer(t, func(string, map[string]interface{}) string {
+			return `{"data":{"user":null},"errors":[{"message":"not allowed","path":["user",0]},{"message":"second"}]}`
+		})
+		defer ts.Close()
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+
+		result := scope.GraphQL(ts.URL).Query(`{ user { name } }`, nil)
+
+		require.Error(t, actualErr)
+		serr, ok := actualErr.(*lash.ScopeErr)
+		require.True(t, ok)
+		assert.Equal(t, "GraphQL", serr.Type)
+		assert.Contains(t, serr.Error(), "not allowed (at user.0); second")
+		assert.Equal(t, http.StatusOK, result.Response().StatusCode())
+	})
+	t.Run("connections are paginated with cursors", func(t *testing.T) {
+		var afters []interface{}
+		ts := makeGraphQLServer(t, func(query string, vars map[string]interface{}) string {
+			afters = append(afters, vars["after"])
+			assert.Equal(t, "acme", vars["org"])
+			switch vars["after"] {
+			case nil:
+				return `{"data":{"org":{"repos":{"edges":[{"node":{"id":1}},{"node":{"id":2}}],"pageInfo":{"hasNextPage":true,"endCursor":"c2"}}}}}`
+			case "c2":
+				return `{"data":{"org":{"repos":{"nodes":[{"id":3}],"pageInfo":{"hasNextPage":false,"endCursor":"c3"}}}}}`
+			}
+			return `{"errors":[{"message":"unexpected cursor"}]}`
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		ch := scope.GraphQL(ts.URL).Paginate(`query($org: String!, $after: String) { ... }`, map[string]interface{}{"org": "acme"}, "org.repos")
+
+		assert.Equal(t, []int{1, 2, 3}, collectIDs(t, ch))
+		assert.Equal(t, []interface{}{nil, "c2"}, afters)
+	})
+	t.Run("a missing connection is an error", func(t *testing.T) {
+		ts := makeGraphQLServer(t, func(string, map[string]interface{}) string {
+			return `{"data":{"org":{}}}`
+		})
+		defer ts.Close()
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+
+		for range scope.GraphQL(ts.URL).Paginate(`{ ... }`, nil, "org.repos") {
+		}
+
+		require.Error(t, actualErr)
+		assert.Equal(t, "Paginate", actualErr.(*lash.ScopeErr).Action)
+	})
+}
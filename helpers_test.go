@@ -27,6 +27,11 @@ func assertFileExists(t *testing.T, filename string) {
 	assert.True(t, !stat.IsDir())
 }
 
+func assertFileNotExists(t *testing.T, filename string) {
+	_, err := os.Stat(filename)
+	assert.True(t, os.IsNotExist(err), "%s exists", filename)
+}
+
 func assertDirExists(t *testing.T, filename string) {
 	stat, err := os.Stat(filename)
 	assert.NoError(t, err)
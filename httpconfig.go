@@ -0,0 +1,248 @@
+package lash
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type (
+	// HTTPConfig for the client shared by every request of a scope (and child scopes),
+	// a request with its own Client is not affected. Changes made with a child scope
+	// only apply to that child
+	HTTPConfig struct {
+		scope *Scope
+	}
+	httpConfig struct {
+		// PEM encoded, trusted as well as the system certificates
+		caCerts     [][]byte
+		certs       []tls.Certificate
+		insecure    bool
+		proxy       *url.URL
+		noRedirects bool
+	}
+)
+
+// HTTP configuration for this scope and child scopes
+func (s *Scope) HTTP() *HTTPConfig {
+	return &HTTPConfig{scope: s}
+}
+
+// CACert trusts the PEM certificates in f as well as the system certificates
+func (c *HTTPConfig) CACert(f *File) *HTTPConfig {
+	pem, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		c.scope.setErr("HTTP", "CACert", err)
+		return c
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+		c.scope.setErr("HTTP", "CACert", xerrors.Errorf("no certificates in '%s'", f.path))
+		return c
+	}
+	c.update(func(cfg *httpConfig) {
+		cfg.caCerts = append(cfg.caCerts, pem)
+	})
+	return c
+}
+
+// ClientCert to present to servers that require one, both files are PEM encoded
+func (c *HTTPConfig) ClientCert(cert, key *File) *HTTPConfig {
+	pair, err := tls.LoadX509KeyPair(cert.path, key.path)
+	if err != nil {
+		c.scope.setErr("HTTP", "ClientCert", err)
+		return c
+	}
+	c.update(func(cfg *httpConfig) {
+		cfg.certs = append(cfg.certs, pair)
+	})
+	return c
+}
+
+// Insecure skips verifying server certificates, only for development servers
+func (c *HTTPConfig) Insecure() *HTTPConfig {
+	c.update(func(cfg *httpConfig) {
+		cfg.insecure = true
+	})
+	return c
+}
+
+// Proxy for all requests, supports EnvStr. Without a proxy the HTTP_PROXY, HTTPS_PROXY
+// and NO_PROXY environment variables are used
+func (c *HTTPConfig) Proxy(proxyURL string, args ...interface{}) *HTTPConfig {
+	u, err := url.Parse(c.scope.EnvStr(proxyURL, args...))
+	if err != nil {
+		c.scope.setErr("HTTP", "Proxy", err)
+		return c
+	}
+	c.update(func(cfg *httpConfig) {
+		cfg.proxy = u
+	})
+	return c
+}
+
+// NoRedirects returns redirect responses rather than following them, use AllowResponses
+// to allow the 3xx status
+func (c *HTTPConfig) NoRedirects() *HTTPConfig {
+	c.update(func(cfg *httpConfig) {
+		cfg.noRedirects = true
+	})
+	return c
+}
+
+// update the config, the shared client is replaced the next time it is used
+func (c *HTTPConfig) update(fn func(cfg *httpConfig)) {
+	h := c.scope.ownHTTP()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fn(&h.config)
+	h.resetClient()
+}
+
+// sharedClient for requests without their own Client, connections are pooled between requests
+func (h *httpState) sharedClient() *http.Client {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.shared != nil {
+		return h.shared
+	}
+
+	cfg := h.config
+	proxy := http.ProxyFromEnvironment
+	if cfg.proxy != nil {
+		proxy = http.ProxyURL(cfg.proxy)
+	}
+	var rootCAs *x509.CertPool
+	if len(cfg.caCerts) != 0 {
+		var err error
+		if rootCAs, err = x509.SystemCertPool(); err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		for _, pem := range cfg.caCerts {
+			rootCAs.AppendCertsFromPEM(pem)
+		}
+	}
+	h.shared = &http.Client{
+		Jar: h.jar,
+		Transport: &http.Transport{
+			Proxy: proxy,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSClientConfig: &tls.Config{
+				RootCAs:            rootCAs,
+				Certificates:       cfg.certs,
+				InsecureSkipVerify: cfg.insecure,
+			},
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   16,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+	if cfg.noRedirects {
+		h.shared.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return h.shared
+}
+
+// client to send with, wrapped to use the cache and to record or replay when required
+func (h *httpState) client(c *http.Client) *http.Client {
+	h.mu.Lock()
+	fx, cache := h.fixtures, h.cache
+	h.mu.Unlock()
+	if fx == nil && cache == nil {
+		return c
+	}
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if fx != nil {
+		next = &fixtureTransport{fixtures: fx, next: next}
+	}
+	if cache != nil {
+		next = &cacheTransport{cache: cache, next: next}
+	}
+	wrapped := *c
+	wrapped.Transport = next
+	return &wrapped
+}
+
+// sharedHTTP state of the scope for sending requests
+func (s *Scope) sharedHTTP() *httpState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.http
+}
+
+// ownHTTP state of the scope to change, the first change made with a child scope copies
+// the state of the parent so the parent and other children are not affected
+func (s *Scope) ownHTTP() *httpState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.httpOwned {
+		s.http = s.http.copy()
+		s.httpOwned = true
+	}
+	return s.http
+}
+
+// copy of the settings, the cookie jar, limits, fixtures, cache and tokens are still
+// shared with h until they are replaced on the copy
+func (h *httpState) copy() *httpState {
+	h.mu.Lock()
+	c := &httpState{
+		jar:      h.jar,
+		fixtures: h.fixtures,
+		cache:    h.cache,
+		config:   h.config,
+		limit:    h.limit,
+		slots:    h.slots,
+	}
+	c.config.caCerts = append([][]byte(nil), h.config.caCerts...)
+	c.config.certs = append([]tls.Certificate(nil), h.config.certs...)
+	if h.hostLimits != nil {
+		c.hostLimits = map[string]*rateLimiter{}
+		for host, l := range h.hostLimits {
+			c.hostLimits[host] = l
+		}
+	}
+	h.mu.Unlock()
+
+	h.tokenMu.Lock()
+	if h.tokens != nil {
+		c.tokens = map[string]*oauthToken{}
+		for key, t := range h.tokens {
+			c.tokens[key] = t
+		}
+	}
+	h.tokenMu.Unlock()
+	return c
+}
+
+// resetClient after a change to the config, mu must be held
+func (h *httpState) resetClient() {
+	if h.shared != nil {
+		h.shared.CloseIdleConnections()
+		h.shared = nil
+	}
+}
+
+// cloneHeader so changes to the copy do not affect h
+func cloneHeader(h http.Header) http.Header {
+	c := http.Header{}
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
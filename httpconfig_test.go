@@ -0,0 +1,166 @@
+package lash_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_http_config(t *testing.T) {
+	t.Run("requests share pooled connections", func(t *testing.T) {
+		var conns int32
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		ts.Config.ConnState = func(c net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt32(&conns, 1)
+			}
+		}
+		ts.Start()
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		for i := 0; i < 3; i++ {
+			scope.Curl(ts.URL).Response()
+			scope.Child().Curl(ts.URL).Response()
+		}
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&conns))
+	})
+	t.Run("a CA certificate is trusted", func(t *testing.T) {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer ts.Close()
+		filename := tempPathname()
+		defer writeFile(t, filename, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})))()
+
+		var actualErr error
+		untrusted := lash.NewScope().OnError(func(err error) { actualErr = err })
+		untrusted.Curl(ts.URL).Response()
+		require.Error(t, actualErr)
+
+		scope := lash.NewScope().OnError(requireNoError(t))
+		scope.HTTP().CACert(scope.OpenFile(filename))
+		assert.Equal(t, http.StatusOK, scope.Curl(ts.URL).Response().StatusCode())
+	})
+	t.Run("insecure skips verification", func(t *testing.T) {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.HTTP().Insecure()
+
+		assert.Equal(t, http.StatusOK, scope.Curl(ts.URL).Response().StatusCode())
+	})
+	t.Run("changes made with a child scope do not affect the parent", func(t *testing.T) {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer ts.Close()
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+		child := scope.Child()
+		sibling := scope.Child().OnError(func(err error) {})
+
+		child.HTTP().Insecure()
+
+		assert.Equal(t, http.StatusOK, child.Curl(ts.URL).Response().StatusCode())
+		assert.NoError(t, child.Err())
+		scope.Curl(ts.URL).Response()
+		assert.Error(t, actualErr)
+		sibling.Curl(ts.URL).Response()
+		assert.Error(t, sibling.Err())
+	})
+	t.Run("a client certificate is presented", func(t *testing.T) {
+		certFile, keyFile, cert := writeClientCert(t)
+		defer func() { _ = os.Remove(certFile); _ = os.Remove(keyFile) }()
+		var commonName string
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commonName = r.TLS.PeerCertificates[0].Subject.CommonName
+		}))
+		pool := x509.NewCertPool()
+		pool.AddCert(cert)
+		ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+		ts.StartTLS()
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.HTTP().Insecure().ClientCert(scope.OpenFile(certFile), scope.OpenFile(keyFile))
+		scope.Curl(ts.URL).Response()
+
+		assert.Equal(t, "lash-client", commonName)
+	})
+	t.Run("requests go via the proxy", func(t *testing.T) {
+		proxy := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("proxied " + r.URL.String()))
+		})
+		defer proxy.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.HTTP().Proxy(proxy.URL)
+
+		assert.Equal(t, "proxied http://example.com/path", scope.Curl("http://example.com/path").Response().BodyString())
+	})
+	t.Run("redirects can be returned rather than followed", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/other", http.StatusFound)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.HTTP().NoRedirects()
+		resp := scope.Curl(ts.URL).AllowResponses(http.StatusFound).Response()
+
+		assert.Equal(t, http.StatusFound, resp.StatusCode())
+		assert.Equal(t, "/other", resp.Header("Location"))
+	})
+	t.Run("a missing certificate is an error", func(t *testing.T) {
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+
+		scope.HTTP().CACert(scope.OpenFile(tempPathname()))
+
+		require.Error(t, actualErr)
+		assert.Equal(t, "CACert", actualErr.(*lash.ScopeErr).Action)
+	})
+}
+
+// writeClientCert as PEM files, returns the cert and key filenames
+func writeClientCert(t *testing.T) (string, string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "lash-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := tempPathname(), tempPathname()
+	writeFile(t, certFile, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	writeFile(t, keyFile, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})))
+	return certFile, keyFile, cert
+}
@@ -0,0 +1,97 @@
+package lash_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_running_external_commands(t *testing.T) {
+	t.Run("output is returned without the trailing new line", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		actual := scope.Exec("echo hello $0", "world").Output()
+
+		assert.Equal(t, "hello world", actual)
+	})
+	t.Run("arguments are split like a shell, quotes keep spaces and EnvStr values are a single argument", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		actual := scope.Exec(`printf '%s|' 'a  b' "c \"d\"" $0`, "e f").Output()
+
+		assert.Equal(t, `a  b|c "d"|e f|`, actual)
+	})
+	t.Run("single quoted and escaped text is not interpolated", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		cost := scope.Exec(`echo 'cost $5' \$HOME`).Output()
+		second := scope.Exec(`awk '{print $2}'`).Stdin(strings.NewReader("a b\nc d\n")).Output()
+
+		assert.Equal(t, "cost $5 $HOME", cost)
+		assert.Equal(t, "b\nd", second)
+	})
+	t.Run("output can be read line by line", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		var lines []string
+		for line := range scope.Exec("printf 'one\ntwo\nthree\n'").Lines() {
+			lines = append(lines, line)
+		}
+
+		assert.Equal(t, []string{"one", "two", "three"}, lines)
+	})
+	t.Run("run writes to the scope output and returns the exit code", func(t *testing.T) {
+		var buf bytes.Buffer
+		scope := lash.NewScope().OnError(requireNoError(t)).SetOutput(&buf)
+
+		code := scope.Exec("echo some text").Run()
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "some text\n", buf.String())
+	})
+	t.Run("dir, env and stdin can be set", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+		dir := os.TempDir()
+		require.NoError(t, os.Setenv("job_test_value", "from-env"))
+
+		actual := scope.
+			Exec("sh -c 'pwd; printenv JOB_VAR; cat'").
+			Dir(dir).
+			Env("JOB_VAR", "$job_test_value").
+			Stdin(strings.NewReader("from stdin")).
+			Output()
+
+		lines := strings.Split(actual, "\n")
+		require.Len(t, lines, 3)
+		assert.Contains(t, lines[0], strings.TrimSuffix(dir, "/"))
+		assert.Equal(t, "from-env", lines[1])
+		assert.Equal(t, "from stdin", lines[2])
+	})
+	t.Run("non-zero exit code is a scope error", func(t *testing.T) {
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+
+		code := scope.Exec("sh -c 'exit 3'").Run()
+
+		assert.Equal(t, 3, code)
+		require.Error(t, actualErr)
+		serr, ok := actualErr.(*lash.ScopeErr)
+		require.True(t, ok)
+		assert.Equal(t, "Job", serr.Type)
+		assert.Equal(t, "Run", serr.Action)
+		assert.Contains(t, actualErr.Error(), "exit status 3")
+	})
+	t.Run("unterminated quotes are an error", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		actual := scope.Exec("echo 'oops").Output()
+
+		assert.Equal(t, "", actual)
+		assert.Contains(t, scope.Err().Error(), "Job:Exec")
+	})
+}
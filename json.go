@@ -0,0 +1,263 @@
+package lash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// JSON value that can be queried with dotted paths e.g. "items.0.id", a missing path or
+// the wrong type is an error for the scope. After an error the value is empty so further
+// calls return zero values without more errors
+type JSON struct {
+	scope *Scope
+	path  string
+	value interface{}
+	ok    bool
+}
+
+// JSON body of the response for querying with paths
+func (r *HTTPResponse) JSON() *JSON {
+	return parseJSON(r.scope, r.body, "HTTPResponse")
+}
+
+// JSON content of the file for querying with paths
+func (f *File) JSON() *JSON {
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		f.scope.SetErr(&ScopeErr{Type: "File", Action: "JSON", Err: xerrors.Errorf("path '%s': %w", f.path, err)})
+		return &JSON{scope: f.scope}
+	}
+	return parseJSON(f.scope, b, "File")
+}
+
+func parseJSON(s *Scope, b []byte, source string) *JSON {
+	j := &JSON{scope: s}
+	var err error
+	if j.value, err = decodeJSON(b); err != nil {
+		s.setErr(source, "JSON", err)
+		return j
+	}
+	j.ok = true
+	return j
+}
+
+// decodeJSON keeping numbers as json.Number so large integers are exact
+func decodeJSON(b []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	err := d.Decode(&v)
+	return v, err
+}
+
+// Get the value at the path, array elements are numbered from 0, an empty path is this value
+func (j *JSON) Get(path string) *JSON {
+	if !j.ok || path == "" {
+		return j
+	}
+	v, ok := j.lookup(path)
+	if !ok {
+		j.fail("Get", xerrors.Errorf("path '%s' not found", j.join(path)))
+	}
+	return &JSON{scope: j.scope, path: j.join(path), value: v, ok: ok}
+}
+
+// Has is true if the path exists, it is never an error
+func (j *JSON) Has(path string) bool {
+	if !j.ok {
+		return false
+	}
+	_, ok := j.lookup(path)
+	return ok
+}
+
+// String value, other types are returned as json text
+func (j *JSON) String() string {
+	if !j.ok {
+		return ""
+	}
+	if s, ok := j.value.(string); ok {
+		return s
+	}
+	return string(j.Raw())
+}
+
+// Int value, a number without a fraction
+func (j *JSON) Int() int {
+	n, ok := j.number("Int")
+	if !ok {
+		return 0
+	}
+	i, err := strconv.Atoi(n.String())
+	if err != nil {
+		j.fail("Int", xerrors.Errorf("path '%s': %w", j.path, err))
+	}
+	return i
+}
+
+// Float value
+func (j *JSON) Float() float64 {
+	n, ok := j.number("Float")
+	if !ok {
+		return 0
+	}
+	f, err := n.Float64()
+	if err != nil {
+		j.fail("Float", xerrors.Errorf("path '%s': %w", j.path, err))
+	}
+	return f
+}
+
+// Bool value
+func (j *JSON) Bool() bool {
+	if !j.ok {
+		return false
+	}
+	b, ok := j.value.(bool)
+	if !ok {
+		j.wrongType("Bool")
+	}
+	return b
+}
+
+// IsNull when the value is json null
+func (j *JSON) IsNull() bool {
+	return j.ok && j.value == nil
+}
+
+// Len of an array or object
+func (j *JSON) Len() int {
+	if !j.ok {
+		return 0
+	}
+	switch v := j.value.(type) {
+	case []interface{}:
+		return len(v)
+	case map[string]interface{}:
+		return len(v)
+	}
+	j.wrongType("Len")
+	return 0
+}
+
+// Each element of the array at the path
+func (j *JSON) Each(path string, fn func(i int, v *JSON)) {
+	v := j.Get(path)
+	if !v.ok {
+		return
+	}
+	arr, ok := v.value.([]interface{})
+	if !ok {
+		v.wrongType("Each")
+		return
+	}
+	for i, item := range arr {
+		fn(i, &JSON{scope: j.scope, path: v.join(strconv.Itoa(i)), value: item, ok: true})
+	}
+}
+
+// Raw json of the value
+func (j *JSON) Raw() json.RawMessage {
+	if !j.ok {
+		return nil
+	}
+	// values came from json so they can always be marshalled
+	b, _ := json.Marshal(j.value)
+	return b
+}
+
+// Decode the value into v, a pointer to a struct etc.
+func (j *JSON) Decode(v interface{}) bool {
+	if !j.ok {
+		return false
+	}
+	if err := json.Unmarshal(j.Raw(), v); err != nil {
+		j.fail("Decode", xerrors.Errorf("path '%s': %w", j.path, err))
+		return false
+	}
+	return true
+}
+
+func (j *JSON) lookup(path string) (interface{}, bool) {
+	current := j.value
+	for _, name := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			var found bool
+			if current, found = v[name]; found {
+				continue
+			}
+		case []interface{}:
+			if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(v) {
+				current = v[i]
+				continue
+			}
+		}
+		return nil, false
+	}
+	return current, true
+}
+
+// jsonRaw at the path of body as per JSON.Get, nil if it does not exist
+func jsonRaw(body []byte, path string) (json.RawMessage, error) {
+	if path == "" {
+		return body, nil
+	}
+	v, err := decodeJSON(body)
+	if err != nil {
+		return nil, xerrors.Errorf("path '%s': %w", path, err)
+	}
+	v, ok := (&JSON{value: v}).lookup(path)
+	if !ok {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func (j *JSON) number(action string) (json.Number, bool) {
+	if !j.ok {
+		return "", false
+	}
+	n, ok := j.value.(json.Number)
+	if !ok {
+		j.wrongType(action)
+	}
+	return n, ok
+}
+
+func (j *JSON) wrongType(action string) {
+	j.fail(action, fmt.Errorf("path '%s' is %s", j.path, jsonType(j.value)))
+}
+
+func (j *JSON) fail(action string, err error) {
+	j.scope.setErr("JSON", action, err)
+}
+
+func (j *JSON) join(path string) string {
+	if j.path == "" {
+		return path
+	}
+	return j.path + "." + path
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a bool"
+	case []interface{}:
+		return "an array"
+	}
+	return "an object"
+}
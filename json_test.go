@@ -0,0 +1,113 @@
+package lash_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const someJSON = `{"name":"any","count":12345678901,"ratio":0.5,"ok":true,"none":null,
+"items":[{"id":"a","tags":["x","y"]},{"id":"b","tags":[]}]}`
+
+func Test_json_paths(t *testing.T) {
+	t.Run("values can be read by path from a response", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(someJSON))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		j := scope.Curl(ts.URL).Response().JSON()
+
+		assert.Equal(t, "any", j.Get("name").String())
+		assert.Equal(t, 12345678901, j.Get("count").Int())
+		assert.Equal(t, 0.5, j.Get("ratio").Float())
+		assert.True(t, j.Get("ok").Bool())
+		assert.True(t, j.Get("none").IsNull())
+		assert.Equal(t, "b", j.Get("items.1.id").String())
+		assert.Equal(t, "y", j.Get("items").Get("0.tags.1").String())
+		assert.Equal(t, 2, j.Get("items").Len())
+		assert.Equal(t, `["x","y"]`, j.Get("items.0.tags").String())
+		assert.True(t, j.Has("items.0.tags"))
+		assert.False(t, j.Has("items.2"))
+	})
+	t.Run("values can be read by path from a file", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, someJSON)()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		var ids []string
+		scope.OpenFile(filename).JSON().Each("items", func(i int, v *lash.JSON) {
+			ids = append(ids, v.Get("id").String())
+		})
+
+		assert.Equal(t, []string{"a", "b"}, ids)
+	})
+	t.Run("values can be decoded", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, someJSON)()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		var item struct {
+			ID   string
+			Tags []string
+		}
+		scope.OpenFile(filename).JSON().Get("items.0").Decode(&item)
+
+		assert.Equal(t, "a", item.ID)
+		assert.Equal(t, []string{"x", "y"}, item.Tags)
+	})
+	t.Run("missing paths are errors", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, someJSON)()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		actual := scope.OpenFile(filename).JSON().Get("items.5").Get("id").String()
+
+		assert.Equal(t, "", actual)
+		require.Error(t, scope.Err())
+		assert.Equal(t, "JSON:Get:path 'items.5' not found", scope.Err().Error())
+	})
+	t.Run("wrong types are errors", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, someJSON)()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		actual := scope.OpenFile(filename).JSON().Get("items.0.id").Int()
+
+		assert.Equal(t, 0, actual)
+		assert.Equal(t, "JSON:Int:path 'items.0.id' is a string", scope.Err().Error())
+	})
+	t.Run("a file can be read after an earlier error", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, `{"id":42}`)()
+		scope := lash.NewScope().OnError(lash.Ignore)
+		scope.SetErr(fmt.Errorf("unrelated"))
+
+		actual := scope.OpenFile(filename).JSON().Get("id").Int()
+
+		assert.Equal(t, 42, actual)
+		assert.EqualError(t, scope.Err(), "unrelated")
+	})
+	t.Run("a missing file is an error", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.OpenFile(tempPathname()).JSON().Get("id")
+
+		require.Error(t, scope.Err())
+		assert.Contains(t, scope.Err().Error(), "File:JSON:path")
+	})
+	t.Run("invalid json is an error", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, "not json")()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.OpenFile(filename).JSON()
+
+		assert.Contains(t, scope.Err().Error(), "File:JSON")
+	})
+}
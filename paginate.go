@@ -0,0 +1,208 @@
+package lash
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type (
+	// Paginator finds the items on a page and the url of the next page, nil when there are no more
+	Paginator interface {
+		Items(page *HTTPResponse) ([]json.RawMessage, error)
+		Next(page *HTTPResponse, items []json.RawMessage) (*url.URL, error)
+	}
+	linkHeader struct{}
+	cursor     struct {
+		items, cursor, param string
+	}
+	pageNumber struct {
+		param, items string
+	}
+	offset struct {
+		param, items string
+	}
+)
+
+// LinkHeader pages have a json array body and the next page is in the Link header with rel="next"
+var LinkHeader Paginator = linkHeader{}
+
+var rxLinkNext = regexp.MustCompile(`<([^>]*)>[^,]*;\s*rel="?next"?`)
+
+// Cursor pages have the items and the next cursor in json body fields (paths as per JSON.Get e.g. "meta.next"),
+// the cursor is sent as the param query string value. There are no more pages when the cursor is empty
+func Cursor(itemsField, cursorField, param string) Paginator {
+	return cursor{items: itemsField, cursor: cursorField, param: param}
+}
+
+// PageNumber increments the param query string value (starting at 1) until a page has no items,
+// itemsField is empty when the body is a json array
+func PageNumber(param, itemsField string) Paginator {
+	return pageNumber{param: param, items: itemsField}
+}
+
+// Offset adds the number of items to the param query string value (starting at 0) until a page has no items,
+// itemsField is empty when the body is a json array
+func Offset(param, itemsField string) Paginator {
+	return offset{param: param, items: itemsField}
+}
+
+// Paginate follows pages and sends every item on the channel, the channel is closed after the last page
+// or an error. Each page is requested with the same settings as this request
+func (cmd *HTTPRequest) Paginate(p Paginator) chan json.RawMessage {
+	ch := make(chan json.RawMessage)
+	ctx := cmd.scope.Context()
+	go func() {
+		defer close(ch)
+		page := cmd
+		for {
+			resp := page.Response()
+			if resp.response == nil || !isInList(resp.response.StatusCode, page.statuses) {
+				return
+			}
+			items, err := p.Items(resp)
+			var next *url.URL
+			if err == nil {
+				next, err = p.Next(resp, items)
+			}
+			if err != nil {
+				cmd.scope.setErr("HTTPResponse", "Paginate", xerrors.Errorf("%s: %w", resp.response.Request.URL, err))
+				return
+			}
+			for _, item := range items {
+				select {
+				case ch <- item:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if next == nil {
+				return
+			}
+			page = cmd.withURL(next)
+		}
+	}()
+	return ch
+}
+
+// headers that are not sent to another host, as net/http does for redirects
+var credentialHeaders = []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2"}
+
+// withURL is a copy of the request with a different url, credentials are only kept for the same host
+func (cmd *HTTPRequest) withURL(u *url.URL) *HTTPRequest {
+	c := *cmd
+	req := *cmd.Req
+	req.URL = u
+	req.Host = ""
+	req.Header = http.Header{}
+	for k, v := range cmd.Req.Header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	if !strings.EqualFold(u.Host, cmd.Req.URL.Host) {
+		for _, name := range credentialHeaders {
+			req.Header.Del(name)
+		}
+	}
+	if req.GetBody != nil {
+		req.Body = nil
+	}
+	c.Req = &req
+	return &c
+}
+
+func (linkHeader) Items(page *HTTPResponse) ([]json.RawMessage, error) {
+	return jsonItems(page.body, "")
+}
+
+func (linkHeader) Next(page *HTTPResponse, _ []json.RawMessage) (*url.URL, error) {
+	for _, link := range page.response.Header["Link"] {
+		if m := rxLinkNext.FindStringSubmatch(link); m != nil {
+			return page.response.Request.URL.Parse(m[1])
+		}
+	}
+	return nil, nil
+}
+
+func (c cursor) Items(page *HTTPResponse) ([]json.RawMessage, error) {
+	return jsonItems(page.body, c.items)
+}
+
+func (c cursor) Next(page *HTTPResponse, _ []json.RawMessage) (*url.URL, error) {
+	raw, err := jsonRaw(page.body, c.cursor)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	if v == nil || v == "" || v == false {
+		return nil, nil
+	}
+	return withQuery(page, c.param, fmt.Sprint(v)), nil
+}
+
+func (p pageNumber) Items(page *HTTPResponse) ([]json.RawMessage, error) {
+	return jsonItems(page.body, p.items)
+}
+
+func (p pageNumber) Next(page *HTTPResponse, items []json.RawMessage) (*url.URL, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	n, err := queryInt(page, p.param, 1)
+	if err != nil {
+		return nil, err
+	}
+	return withQuery(page, p.param, strconv.Itoa(n+1)), nil
+}
+
+func (o offset) Items(page *HTTPResponse) ([]json.RawMessage, error) {
+	return jsonItems(page.body, o.items)
+}
+
+func (o offset) Next(page *HTTPResponse, items []json.RawMessage) (*url.URL, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	n, err := queryInt(page, o.param, 0)
+	if err != nil {
+		return nil, err
+	}
+	return withQuery(page, o.param, strconv.Itoa(n+len(items))), nil
+}
+
+func queryInt(page *HTTPResponse, param string, def int) (int, error) {
+	v := page.response.Request.URL.Query().Get(param)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
+
+func withQuery(page *HTTPResponse, param, value string) *url.URL {
+	u := *page.response.Request.URL
+	q := u.Query()
+	q.Set(param, value)
+	u.RawQuery = q.Encode()
+	return &u
+}
+
+// jsonItems from the field of a json object, or the whole body when field is empty
+func jsonItems(body []byte, field string) ([]json.RawMessage, error) {
+	raw, err := jsonRaw(body, field)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, xerrors.Errorf("items '%s': %w", field, err)
+	}
+	return items, nil
+}
@@ -0,0 +1,141 @@
+package lash_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+)
+
+func collectIDs(t *testing.T, ch chan json.RawMessage) []int {
+	var ids []int
+	for item := range ch {
+		var v struct{ ID int }
+		assert.NoError(t, json.Unmarshal(item, &v))
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+func Test_pagination(t *testing.T) {
+	t.Run("link header", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "kept", r.Header.Get("X-Custom"))
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page < 2 {
+				w.Header().Set("Link", fmt.Sprintf(`</items?page=%d>; rel="next", </items?page=0>; rel="first"`, page+1))
+			}
+			_, _ = fmt.Fprintf(w, `[{"id":%d},{"id":%d}]`, page*2, page*2+1)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		ids := collectIDs(t, scope.Curl(ts.URL+"/items").Header("X-Custom", "kept").Paginate(lash.LinkHeader))
+
+		assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, ids)
+	})
+	t.Run("credentials are not sent to another host", func(t *testing.T) {
+		var auth []string
+		other := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			auth = append(auth, r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`[{"id":2}]`))
+		})
+		defer other.Close()
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			auth = append(auth, r.Header.Get("Authorization"))
+			w.Header().Set("Link", fmt.Sprintf(`<%s/items>; rel="next"`, other.URL))
+			_, _ = w.Write([]byte(`[{"id":1}]`))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		ids := collectIDs(t, scope.Curl(ts.URL).AuthBearer("secret").Paginate(lash.LinkHeader))
+
+		assert.Equal(t, []int{1, 2}, ids)
+		assert.Equal(t, []string{"Bearer secret", ""}, auth)
+	})
+	t.Run("cursor in the body", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("after") {
+			case "":
+				_, _ = w.Write([]byte(`{"data":[{"id":1}],"meta":{"next":"abc"}}`))
+			case "abc":
+				_, _ = w.Write([]byte(`{"data":[{"id":2}],"meta":{"next":null}}`))
+			}
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		ids := collectIDs(t, scope.Curl(ts.URL).Paginate(lash.Cursor("data", "meta.next", "after")))
+
+		assert.Equal(t, []int{1, 2}, ids)
+	})
+	t.Run("paths can index arrays", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("after") {
+			case "":
+				_, _ = w.Write([]byte(`{"pages":[{"data":[{"id":1}],"links":[{"next":"abc"}]}]}`))
+			case "abc":
+				_, _ = w.Write([]byte(`{"pages":[{"data":[{"id":2}],"links":[]}]}`))
+			}
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		ids := collectIDs(t, scope.Curl(ts.URL).Paginate(lash.Cursor("pages.0.data", "pages.0.links.0.next", "after")))
+
+		assert.Equal(t, []int{1, 2}, ids)
+	})
+	t.Run("page number", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("page") {
+			case "":
+				_, _ = w.Write([]byte(`{"results":[{"id":1}]}`))
+			case "2":
+				_, _ = w.Write([]byte(`{"results":[{"id":2}]}`))
+			default:
+				_, _ = w.Write([]byte(`{"results":[]}`))
+			}
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		ids := collectIDs(t, scope.Curl(ts.URL).Paginate(lash.PageNumber("page", "results")))
+
+		assert.Equal(t, []int{1, 2}, ids)
+	})
+	t.Run("offset", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("offset") {
+			case "", "0":
+				_, _ = w.Write([]byte(`[{"id":1},{"id":2}]`))
+			case "2":
+				_, _ = w.Write([]byte(`[{"id":3}]`))
+			default:
+				_, _ = w.Write([]byte(`[]`))
+			}
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		ids := collectIDs(t, scope.Curl(ts.URL+"?offset=0").Paginate(lash.Offset("offset", "")))
+
+		assert.Equal(t, []int{1, 2, 3}, ids)
+	})
+	t.Run("a body that is not a page is an error", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"not":"an array"}`))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		ids := collectIDs(t, scope.Curl(ts.URL).Paginate(lash.LinkHeader))
+
+		assert.Empty(t, ids)
+		assert.Contains(t, scope.Err().Error(), "HTTPResponse:Paginate")
+	})
+}
@@ -0,0 +1,235 @@
+package lash
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type (
+	// Pipeline of jobs, the stdout of each stage is the stdin of the next
+	Pipeline struct {
+		scope      *Scope
+		jobs       []*Job
+		stdin      io.Reader
+		closeStdin func()
+	}
+)
+
+// Pipeline of commands, as per Exec, "cat x | grep foo | sort" is
+// scope.Pipeline("cat x", "grep foo", "sort"). Single quoted text is not interpolated
+// so awk '{print $2}' is passed on unchanged
+func (s *Scope) Pipeline(commands ...string) *Pipeline {
+	p := &Pipeline{scope: s}
+	for _, c := range commands {
+		p.jobs = append(p.jobs, s.Exec(c))
+	}
+	return p
+}
+
+// Pipe existing jobs together, use when stages need arguments
+func (s *Scope) Pipe(jobs ...*Job) *Pipeline {
+	return &Pipeline{scope: s, jobs: jobs}
+}
+
+// Stdin for the first stage
+func (p *Pipeline) Stdin(r io.Reader) *Pipeline {
+	p.stdin = r
+	return p
+}
+
+// StdinBytes for the first stage, e.g. HTTPResponse.BodyBytes()
+func (p *Pipeline) StdinBytes(b []byte) *Pipeline {
+	return p.Stdin(bytes.NewReader(b))
+}
+
+// StdinLines for the first stage, e.g. File.ReadLines(), each line is new line terminated
+func (p *Pipeline) StdinLines(ch <-chan string) *Pipeline {
+	r := linesReader(ch)
+	p.closeStdin = func() { _ = r.Close() }
+	return p.Stdin(r)
+}
+
+// Run the pipeline, output of the last stage goes to the scope output
+func (p *Pipeline) Run() {
+	p.runTo(p.scope.stdout)
+}
+
+// Output of the last stage as a string, trailing new lines are removed
+func (p *Pipeline) Output() string {
+	var buf bytes.Buffer
+	p.runTo(&buf)
+	return strings.TrimRight(buf.String(), "\r\n")
+}
+
+// ToFile appends the output of the last stage to the file, use File.Truncate first to replace the content
+func (p *Pipeline) ToFile(f *File) *File {
+	if f.scope.dryRunf("write output to '%s'", f.path) {
+		// reports the commands
+		p.runTo(ioutil.Discard)
+		return f
+	}
+	f.open(openBasic)
+	if f.file == nil {
+		return f
+	}
+	p.runTo(f.file)
+	return f
+}
+
+// ToAppender sends each line of the output of the last stage to the appender
+func (p *Pipeline) ToAppender(a FileAppender) {
+	for line := range p.Lines() {
+		a.Ch() <- line
+	}
+}
+
+// Lines of output of the last stage via a channel one line at a time, see File.ReadLines
+func (p *Pipeline) Lines() chan string {
+	ch := make(chan string)
+	r, w, err := os.Pipe()
+	if err != nil {
+		p.scope.setErr("Pipeline", "Lines", err)
+		close(ch)
+		return ch
+	}
+	wait := p.start(w)
+	_ = w.Close()
+	if wait == nil {
+		_ = r.Close()
+		close(ch)
+		return ch
+	}
+
+	go func() {
+		defer close(ch)
+		scanner := bufio.NewScanner(r)
+		scanner.Split(bufio.ScanLines)
+		for scanner.Scan() {
+			ch <- scanner.Text()
+		}
+		_ = r.Close()
+		wait()
+	}()
+
+	return ch
+}
+
+func (p *Pipeline) runTo(stdout io.Writer) {
+	if wait := p.start(stdout); wait != nil {
+		wait()
+	}
+}
+
+// start all stages, the returned func waits for them all to finish and reports
+// the first stage to fail, nil is returned if the pipeline could not be started or this is a dry run
+func (p *Pipeline) start(stdout io.Writer) func() {
+	if len(p.jobs) == 0 {
+		p.scope.setErr("Pipeline", "Start", xerrors.New("no stages"))
+		return nil
+	}
+	for _, j := range p.jobs {
+		if j.Cmd == nil {
+			// Exec has already reported the error
+			return nil
+		}
+	}
+	if p.dryRun() {
+		return nil
+	}
+
+	var pipes []*os.File
+	closePipes := func() {
+		for _, f := range pipes {
+			_ = f.Close()
+		}
+	}
+	if p.stdin != nil {
+		p.jobs[0].Cmd.Stdin = p.stdin
+	}
+	for i := 0; i < len(p.jobs)-1; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			closePipes()
+			p.scope.setErr("Pipeline", "Start", err)
+			return nil
+		}
+		p.jobs[i].Cmd.Stdout = w
+		p.jobs[i+1].Cmd.Stdin = r
+		pipes = append(pipes, r, w)
+	}
+	p.jobs[len(p.jobs)-1].Cmd.Stdout = stdout
+
+	started := 0
+	for _, j := range p.jobs {
+		j.Cmd.Stderr = p.scope.stderr
+		if err := j.Cmd.Start(); err != nil {
+			p.fail(started, err)
+			break
+		}
+		started++
+	}
+	// the children have their own copies of the pipes
+	closePipes()
+
+	return func() {
+		failed := started < len(p.jobs)
+		for i, j := range p.jobs[:started] {
+			if err := j.Cmd.Wait(); err != nil && !failed {
+				failed = true
+				p.fail(i, err)
+			}
+		}
+		if p.closeStdin != nil {
+			// unblock the feeder if the first stage did not read everything
+			p.closeStdin()
+		}
+	}
+}
+
+// dryRun reports the command lines, true if they must not be run
+func (p *Pipeline) dryRun() bool {
+	lines := make([]string, len(p.jobs))
+	for i, j := range p.jobs {
+		lines[i] = j.line
+	}
+	if !p.scope.dryRunf("run '%s'", strings.Join(lines, " | ")) {
+		return false
+	}
+	if p.closeStdin != nil {
+		// nothing reads the input so let the feeder finish
+		p.closeStdin()
+	}
+	return true
+}
+
+func (p *Pipeline) fail(stage int, err error) {
+	p.scope.SetErr(&ScopeErr{
+		Type:   "Pipeline",
+		Action: fmt.Sprintf("stage %d", stage+1),
+		Err:    xerrors.Errorf("'%s': %w", p.jobs[stage].line, err),
+	})
+}
+
+// linesReader turns a channel of lines into a reader of new line terminated text
+func linesReader(ch <-chan string) *io.PipeReader {
+	r, w := io.Pipe()
+	go func() {
+		for line := range ch {
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				break
+			}
+		}
+		// drain so the sender is never blocked
+		for range ch {
+		}
+		_ = w.Close()
+	}()
+	return r
+}
@@ -0,0 +1,108 @@
+package lash_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pipelines(t *testing.T) {
+	t.Run("output of each stage is the input of the next", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, "pear\napple\nfig\nbanana\n")()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		var lines []string
+		for line := range scope.Pipeline("cat "+filename, "grep a", "sort").Lines() {
+			lines = append(lines, line)
+		}
+
+		assert.Equal(t, []string{"apple", "banana", "pear"}, lines)
+	})
+	t.Run("stages with arguments can be piped", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		actual := scope.Pipe(
+			scope.Exec("echo $0", "one two three"),
+			scope.Exec("tr ' ' '\n'"),
+			scope.Exec("wc -l"),
+		).Output()
+
+		assert.Contains(t, actual, "3")
+	})
+	t.Run("single quoted stages reach the command unchanged", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		actual := scope.Pipeline(`printf 'a b\nc d\n'`, `awk '{print $2}'`, `sed 's/$/!/'`).Output()
+
+		assert.Equal(t, "b!\nd!", actual)
+	})
+	t.Run("file lines can be the input", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, "b\na\nc")()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		actual := scope.
+			Pipeline("sort -r").
+			StdinLines(scope.OpenFile(filename).ReadLines()).
+			Output()
+
+		assert.Equal(t, "c\nb\na", actual)
+	})
+	t.Run("http response body can be the input", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("hello from http"))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		actual := scope.
+			Pipeline("tr a-z A-Z").
+			StdinBytes(scope.Curl(ts.URL).Response().BodyBytes()).
+			Output()
+
+		assert.Equal(t, "HELLO FROM HTTP", actual)
+	})
+	t.Run("output can be sent to a file", func(t *testing.T) {
+		filename := tempPathname()
+		defer func() { _ = os.Remove(filename) }()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Pipeline("echo some text").ToFile(scope.OpenFile(filename)).Close()
+
+		actual, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, "some text\n", string(actual))
+	})
+	t.Run("output can be sent to an appender", func(t *testing.T) {
+		filename := tempPathname()
+		defer func() { _ = os.Remove(filename) }()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		appender := scope.OpenFile(filename).Appender()
+		scope.Pipeline("printf 'one\ntwo\n'").ToAppender(appender)
+		appender.Close()
+
+		actual, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, "one\ntwo\n", string(actual))
+	})
+	t.Run("a failing stage is identified in the error", func(t *testing.T) {
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+
+		scope.Pipeline("echo any", "sh -c 'cat; exit 2'", "cat").Output()
+
+		require.Error(t, actualErr)
+		serr, ok := actualErr.(*lash.ScopeErr)
+		require.True(t, ok)
+		assert.Equal(t, "Pipeline", serr.Type)
+		assert.Equal(t, "stage 2", serr.Action)
+		assert.Contains(t, actualErr.Error(), "exit status 2")
+	})
+}
@@ -0,0 +1,36 @@
+package lash
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Query adds a query string parameter, the value supports EnvStr and is escaped after interpolation
+// so values such as a line from a file can contain spaces, & or =
+func (cmd *HTTPRequest) Query(key, value string, args ...interface{}) *HTTPRequest {
+	q := cmd.Req.URL.Query()
+	q.Add(key, cmd.scope.EnvStr(value, args...))
+	cmd.Req.URL.RawQuery = q.Encode()
+	return cmd
+}
+
+// QueryMap adds each key and value as per Query
+func (cmd *HTTPRequest) QueryMap(values map[string]string) *HTTPRequest {
+	q := cmd.Req.URL.Query()
+	for k, v := range values {
+		q.Add(k, cmd.scope.EnvStr(v))
+	}
+	cmd.Req.URL.RawQuery = q.Encode()
+	return cmd
+}
+
+// Path appends a single segment to the url path, the segment supports EnvStr and is escaped
+// after interpolation so it can contain /, ? or spaces
+func (cmd *HTTPRequest) Path(segment string, args ...interface{}) *HTTPRequest {
+	segment = cmd.scope.EnvStr(segment, args...)
+	u := cmd.Req.URL
+	escaped := strings.TrimSuffix(u.EscapedPath(), "/") + "/" + url.PathEscape(segment)
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + segment
+	u.RawPath = escaped
+	return cmd
+}
@@ -0,0 +1,60 @@
+package lash_test
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_building_urls(t *testing.T) {
+	t.Run("query values are escaped after interpolation", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		req := scope.
+			Curl("https://example.com/search?existing=1").
+			Query("q", "$0", "fish & chips=yes").
+			Query("q", "second")
+
+		assert.Equal(t, "https://example.com/search?existing=1&q=fish+%26+chips%3Dyes&q=second", req.Req.URL.String())
+	})
+	t.Run("query values can be a map", func(t *testing.T) {
+		require.NoError(t, os.Setenv("query_test_value", "a b"))
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		req := scope.
+			Curl("https://example.com").
+			QueryMap(map[string]string{"one": "$query_test_value", "two": "2"})
+
+		assert.Equal(t, "a b", req.Req.URL.Query().Get("one"))
+		assert.Equal(t, "2", req.Req.URL.Query().Get("two"))
+	})
+	t.Run("path segments are escaped after interpolation", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		req := scope.
+			Curl("https://example.com/api/").
+			Path("users").
+			Path("$0", "a/b c?").
+			Query("x", "y")
+
+		assert.Equal(t, "https://example.com/api/users/a%2Fb%20c%3F?x=y", req.Req.URL.String())
+	})
+	t.Run("the server receives the original values", func(t *testing.T) {
+		var path, query string
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.EscapedPath()
+			query = r.URL.Query().Get("q")
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		scope.Curl(ts.URL).Path("$0", "x/y").Query("q", "a&b").Response()
+
+		assert.Equal(t, "/x%2Fy", path)
+		assert.Equal(t, "a&b", query)
+	})
+}
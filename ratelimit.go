@@ -0,0 +1,154 @@
+package lash
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type (
+	// rateLimiter is a token bucket, it starts full so the first n requests are not delayed
+	rateLimiter struct {
+		mu     sync.Mutex
+		tokens float64
+		max    float64
+		// tokens per nanosecond
+		rate float64
+		last time.Time
+	}
+	// releaseBody gives back a concurrency slot when the response body is closed
+	releaseBody struct {
+		io.ReadCloser
+		once    sync.Once
+		release func()
+	}
+)
+
+// RateLimit http requests for this scope (and child scopes) to n every per, e.g. RateLimit(10, time.Second).
+// The limit is shared by requests from any go routine, each retry counts as a request
+func (s *Scope) RateLimit(n int, per time.Duration) *Scope {
+	l, err := newRateLimiter(n, per)
+	if err != nil {
+		s.setErr("Scope", "RateLimit", err)
+		return s
+	}
+	h := s.ownHTTP()
+	h.mu.Lock()
+	h.limit = l
+	h.mu.Unlock()
+	return s
+}
+
+// RateLimitHost as RateLimit but only for requests to host, the host can include the port.
+// Requests to the host must also be within the RateLimit of the scope if there is one
+func (s *Scope) RateLimitHost(host string, n int, per time.Duration) *Scope {
+	l, err := newRateLimiter(n, per)
+	if err != nil {
+		s.setErr("Scope", "RateLimit", err)
+		return s
+	}
+	host = s.EnvStr(host)
+	h := s.ownHTTP()
+	h.mu.Lock()
+	if h.hostLimits == nil {
+		h.hostLimits = map[string]*rateLimiter{}
+	}
+	h.hostLimits[host] = l
+	h.mu.Unlock()
+	return s
+}
+
+// MaxConcurrent http requests for this scope (and child scopes), a request is in progress
+// until its response body has been read
+func (s *Scope) MaxConcurrent(n int) *Scope {
+	if n < 1 {
+		s.setErr("Scope", "MaxConcurrent", xerrors.Errorf("must be at least 1, got %d", n))
+		return s
+	}
+	h := s.ownHTTP()
+	h.mu.Lock()
+	h.slots = make(chan struct{}, n)
+	h.mu.Unlock()
+	return s
+}
+
+func newRateLimiter(n int, per time.Duration) (*rateLimiter, error) {
+	if n < 1 || per <= 0 {
+		return nil, xerrors.Errorf("invalid limit %d every %v", n, per)
+	}
+	return &rateLimiter{
+		tokens: float64(n),
+		max:    float64(n),
+		rate:   float64(n) / float64(per),
+		last:   time.Now(),
+	}, nil
+}
+
+// wait for a token, callers are served in the order they arrive
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += float64(now.Sub(l.last)) * l.rate
+	if l.tokens > l.max {
+		l.tokens = l.max
+	}
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate)
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give the token back for the next caller
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// acquire permission to send to u, release must be called when the request is complete
+func (h *httpState) acquire(ctx context.Context, u *url.URL) (func(), error) {
+	h.mu.Lock()
+	limit, slots := h.limit, h.slots
+	host := h.hostLimits[u.Host]
+	if host == nil {
+		host = h.hostLimits[u.Hostname()]
+	}
+	h.mu.Unlock()
+
+	for _, l := range []*rateLimiter{host, limit} {
+		if l == nil {
+			continue
+		}
+		if err := l.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
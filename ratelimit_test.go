@@ -0,0 +1,98 @@
+package lash_test
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_rate_limits(t *testing.T) {
+	t.Run("requests are spread out by the scope limit", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t)).RateLimit(2, 100*time.Millisecond)
+
+		start := time.Now()
+		scope.ForEach(numberedLines(5), 5, func(s *lash.Scope, line string) {
+			s.Curl(ts.URL).Response()
+		})
+
+		// two are sent at once then one every 50ms
+		assert.True(t, time.Since(start) >= 150*time.Millisecond, "took %v", time.Since(start))
+	})
+	t.Run("host limits only apply to that host", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {})
+		defer ts.Close()
+		u, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		scope := lash.NewScope().OnError(requireNoError(t)).RateLimitHost("other.example.com", 1, time.Hour)
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			scope.Curl(ts.URL).Response()
+		}
+		assert.True(t, time.Since(start) < time.Second)
+
+		scope.RateLimitHost(u.Host, 1, time.Hour)
+		scope.Curl(ts.URL).Response()
+		ctxScope := scope.Child().WithTimeout(50 * time.Millisecond).OnError(func(error) {})
+		ctxScope.Curl(ts.URL).Response()
+		err = ctxScope.Err()
+		require.Error(t, err)
+		assert.Equal(t, "RateLimit", err.(*lash.ScopeErr).Action)
+	})
+	t.Run("concurrent requests are capped", func(t *testing.T) {
+		var mu sync.Mutex
+		inFlight, maxInFlight := 0, 0
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t)).MaxConcurrent(2)
+
+		scope.ForEach(numberedLines(8), 8, func(s *lash.Scope, line string) {
+			s.Curl(ts.URL).Response()
+		})
+
+		assert.Equal(t, 2, maxInFlight)
+	})
+	t.Run("a limit set with a child scope does not slow the parent", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+		child := scope.Child().RateLimit(1, time.Hour)
+		child.Curl(ts.URL).Response()
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			scope.Curl(ts.URL).Response()
+		}
+
+		assert.True(t, time.Since(start) < time.Second)
+		child.End()
+	})
+	t.Run("invalid limits are an error", func(t *testing.T) {
+		var actualErr error
+		scope := lash.NewScope().OnError(func(err error) { actualErr = err })
+
+		scope.RateLimit(0, time.Second)
+
+		require.Error(t, actualErr)
+		assert.Equal(t, "RateLimit", actualErr.(*lash.ScopeErr).Action)
+	})
+}
@@ -0,0 +1,83 @@
+package lash
+
+import (
+	"mime"
+	"net/http"
+	"net/http/cookiejar"
+	"time"
+)
+
+// CookieJar keeps cookies between requests made with this scope (and child scopes),
+// e.g. a login call sets a session cookie that later Curl calls send
+func (s *Scope) CookieJar() *Scope {
+	h := s.ownHTTP()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.jar == nil {
+		// cookiejar.New only fails with invalid options
+		h.jar, _ = cookiejar.New(nil)
+		h.resetClient()
+	}
+	return s
+}
+
+// Header value of the response, empty if not present
+func (r *HTTPResponse) Header(name string) string {
+	if r == nil || r.response == nil {
+		return ""
+	}
+	return r.response.Header.Get(name)
+}
+
+// Headers of the response
+func (r *HTTPResponse) Headers() http.Header {
+	if r == nil || r.response == nil {
+		return http.Header{}
+	}
+	return r.response.Header
+}
+
+// Cookies set by the response
+func (r *HTTPResponse) Cookies() []*http.Cookie {
+	if r == nil || r.response == nil {
+		return nil
+	}
+	return r.response.Cookies()
+}
+
+// ContentType media type without parameters, e.g. "application/json"
+func (r *HTTPResponse) ContentType() string {
+	ct := r.Header("Content-Type")
+	if mt, _, err := mime.ParseMediaType(ct); err == nil {
+		return mt
+	}
+	return ct
+}
+
+// Duration of the request including retries and reading the body
+func (r *HTTPResponse) Duration() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return r.duration
+}
+
+// URL of the response after any redirects
+func (r *HTTPResponse) URL() string {
+	if r == nil || r.response == nil || r.response.Request == nil {
+		return ""
+	}
+	return r.response.Request.URL.String()
+}
+
+// Redirects that were followed, the urls in the order they were requested not including the final URL
+func (r *HTTPResponse) Redirects() []string {
+	if r == nil || r.response == nil || r.response.Request == nil {
+		return nil
+	}
+	var chain []string
+	for via := r.response.Request.Response; via != nil && via.Request != nil; via = via.Request.Response {
+		chain = append([]string{via.Request.URL.String()}, chain...)
+	}
+	return chain
+}
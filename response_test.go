@@ -0,0 +1,89 @@
+package lash_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_response_metadata(t *testing.T) {
+	t.Run("headers, cookies and content type", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Add("X-Many", "one")
+			w.Header().Add("X-Many", "two")
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+			time.Sleep(10 * time.Millisecond)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		resp := scope.Curl(ts.URL).Response()
+
+		assert.Equal(t, `"v1"`, resp.Header("etag"))
+		assert.Equal(t, []string{"one", "two"}, resp.Headers()["X-Many"])
+		assert.Equal(t, "application/json", resp.ContentType())
+		require.Len(t, resp.Cookies(), 1)
+		assert.Equal(t, "abc", resp.Cookies()[0].Value)
+		assert.True(t, resp.Duration() >= 10*time.Millisecond)
+	})
+	t.Run("final url and redirect chain", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/one":
+				http.Redirect(w, r, "/two", http.StatusFound)
+			case "/two":
+				http.Redirect(w, r, "/three", http.StatusMovedPermanently)
+			}
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		resp := scope.Curl(ts.URL + "/one").Response()
+
+		assert.Equal(t, ts.URL+"/three", resp.URL())
+		assert.Equal(t, []string{ts.URL + "/one", ts.URL + "/two"}, resp.Redirects())
+	})
+	t.Run("no redirects", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		resp := scope.Curl(ts.URL).Response()
+
+		assert.Equal(t, ts.URL, resp.URL())
+		assert.Empty(t, resp.Redirects())
+	})
+	t.Run("cookie jar keeps cookies between requests", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/login" {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "logged-in", Path: "/"})
+				return
+			}
+			c, err := r.Cookie("session")
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(c.Value))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.Curl(ts.URL + "/login").Post([]byte("user")).Response()
+		assert.Equal(t, http.StatusUnauthorized, scope.Curl(ts.URL+"/data").Response().StatusCode())
+
+		scope.ClearError()
+		scope.CookieJar()
+		scope.Curl(ts.URL + "/login").Post([]byte("user")).Response()
+		resp := scope.Curl(ts.URL + "/data").Response()
+
+		assert.NoError(t, scope.Err())
+		assert.Equal(t, "logged-in", resp.BodyString())
+	})
+}
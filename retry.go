@@ -0,0 +1,107 @@
+package lash
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type retryPolicy struct {
+	attempts int
+	initial  time.Duration
+	max      time.Duration
+	jitter   float64
+	statuses []int
+}
+
+func defaultRetryPolicy() retryPolicy {
+	return retryPolicy{
+		initial:  500 * time.Millisecond,
+		max:      30 * time.Second,
+		jitter:   0.2,
+		statuses: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// Retry up to n more times after transport errors or a RetryOn status, the default is no retries
+func (cmd *HTTPRequest) Retry(n int) *HTTPRequest {
+	cmd.retry.attempts = n
+	return cmd
+}
+
+// Backoff between retries starts at initial and doubles each time up to max,
+// the default is 500ms to 30s. A Retry-After header is used instead when present, up to max
+func (cmd *HTTPRequest) Backoff(initial, max time.Duration) *HTTPRequest {
+	cmd.retry.initial = initial
+	cmd.retry.max = max
+	return cmd
+}
+
+// Jitter randomises each backoff by +/- the fraction (0 to 1) so many clients do not retry in step,
+// the default is 0.2
+func (cmd *HTTPRequest) Jitter(fraction float64) *HTTPRequest {
+	cmd.retry.jitter = fraction
+	return cmd
+}
+
+// RetryOn overrides the statuses that are retried (429, 502, 503, 504)
+func (cmd *HTTPRequest) RetryOn(status ...int) *HTTPRequest {
+	cmd.retry.statuses = status
+	return cmd
+}
+
+func (p retryPolicy) retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	for _, s := range p.statuses {
+		if s == resp.StatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// wait before the next attempt, attempt is zero based
+func (p retryPolicy) wait(attempt int, resp *http.Response) time.Duration {
+	if d, ok := retryAfter(resp); ok {
+		if d > p.max {
+			d = p.max
+		}
+		return d
+	}
+	d := p.initial
+	for i := 0; i < attempt && d < p.max; i++ {
+		d *= 2
+	}
+	if d > p.max {
+		d = p.max
+	}
+	if p.jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.jitter * float64(d))
+	}
+	return d
+}
+
+// retryAfter header as either seconds or an http date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,110 @@
+package lash_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_http_requests_can_be_retried(t *testing.T) {
+	t.Run("retryable statuses are retried and the body is sent each time", func(t *testing.T) {
+		var bodies []string
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			buf, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(buf))
+			if len(bodies) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("done"))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		resp := scope.
+			Curl(ts.URL).
+			Post([]byte("the body")).
+			Retry(3).
+			Backoff(time.Millisecond, 5*time.Millisecond).
+			Response()
+
+		assert.Equal(t, "done", resp.BodyString())
+		assert.Equal(t, []string{"the body", "the body", "the body"}, bodies)
+	})
+	t.Run("gives up after the number of retries", func(t *testing.T) {
+		calls := 0
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		resp := scope.
+			Curl(ts.URL).
+			Retry(2).
+			Backoff(time.Millisecond, time.Millisecond).
+			Response()
+
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode())
+		require.Error(t, scope.Err())
+		assert.Contains(t, scope.Err().Error(), "status 429 not allowed")
+	})
+	t.Run("other statuses are not retried unless listed", func(t *testing.T) {
+		calls := 0
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		scope.Curl(ts.URL).Retry(2).Backoff(time.Millisecond, time.Millisecond).Response()
+		assert.Equal(t, 1, calls)
+
+		scope.Curl(ts.URL).Retry(2).Backoff(time.Millisecond, time.Millisecond).RetryOn(500).Response()
+		assert.Equal(t, 4, calls)
+	})
+	t.Run("retry after header is respected", func(t *testing.T) {
+		calls := 0
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		start := time.Now()
+		scope.Curl(ts.URL).Retry(1).Backoff(time.Millisecond, 2*time.Second).Response()
+
+		assert.Equal(t, 2, calls)
+		assert.True(t, time.Since(start) >= time.Second)
+	})
+	t.Run("retry after is limited to the max backoff", func(t *testing.T) {
+		calls := 0
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.Header().Set("Retry-After", "86400")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		start := time.Now()
+		scope.Curl(ts.URL).Retry(1).Backoff(time.Millisecond, 50*time.Millisecond).Response()
+
+		assert.Equal(t, 2, calls)
+		assert.True(t, time.Since(start) < time.Second, "took %v", time.Since(start))
+	})
+}
@@ -0,0 +1,265 @@
+package lash
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// schema validates a value against the commonly used parts of JSON Schema:
+// type, enum, const, properties, required, additionalProperties, items, min/maxItems,
+// min/maxLength, pattern, minimum, maximum, exclusiveMinimum/Maximum, allOf, anyOf, oneOf,
+// not and local $ref ("#/definitions/name")
+type schema struct {
+	root map[string]interface{}
+}
+
+func (s schema) validate(v interface{}) []string {
+	return s.check(s.root, v, "$", nil)
+}
+
+// check v against sc, refs are the $refs already followed for this path so a $ref that
+// leads back to itself without moving into the value is reported rather than followed forever
+func (s schema) check(sc map[string]interface{}, v interface{}, path string, refs map[string]bool) []string {
+	if ref, ok := sc["$ref"].(string); ok {
+		if refs[ref] {
+			return []string{fmt.Sprintf("%s: $ref '%s' is a cycle", path, ref)}
+		}
+		target, err := s.resolve(ref)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", path, err)}
+		}
+		// a copy, the same $ref in different branches is not a cycle
+		followed := map[string]bool{ref: true}
+		for r := range refs {
+			followed[r] = true
+		}
+		return s.check(target, v, path, followed)
+	}
+	var errs []string
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := sc["type"]; ok && !typeMatches(t, v) {
+		fail("expected type %v, got %s", t, jsonType(v))
+		return errs
+	}
+	if enum, ok := sc["enum"].([]interface{}); ok && !inValues(enum, v) {
+		fail("%s is not one of %s", compact(v), compact(enum))
+	}
+	if c, ok := sc["const"]; ok && !reflect.DeepEqual(normalise(c), normalise(v)) {
+		fail("expected %s, got %s", compact(c), compact(v))
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		props, _ := sc["properties"].(map[string]interface{})
+		for _, r := range asSlice(sc["required"]) {
+			if name, ok := r.(string); ok {
+				if _, found := val[name]; !found {
+					fail("required property '%s' is missing", name)
+				}
+			}
+		}
+		for name, pv := range val {
+			if ps, ok := props[name].(map[string]interface{}); ok {
+				errs = append(errs, s.check(ps, pv, path+"."+name, nil)...)
+				continue
+			}
+			switch extra := sc["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					fail("property '%s' is not allowed", name)
+				}
+			case map[string]interface{}:
+				errs = append(errs, s.check(extra, pv, path+"."+name, nil)...)
+			}
+		}
+	case []interface{}:
+		if n, ok := schemaNumber(sc["minItems"]); ok && float64(len(val)) < n {
+			fail("expected at least %v items, got %d", n, len(val))
+		}
+		if n, ok := schemaNumber(sc["maxItems"]); ok && float64(len(val)) > n {
+			fail("expected at most %v items, got %d", n, len(val))
+		}
+		if items, ok := sc["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				errs = append(errs, s.check(items, item, path+"."+strconv.Itoa(i), nil)...)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(val))
+		if n, ok := schemaNumber(sc["minLength"]); ok && length < n {
+			fail("expected length at least %v, got %v", n, length)
+		}
+		if n, ok := schemaNumber(sc["maxLength"]); ok && length > n {
+			fail("expected length at most %v, got %v", n, length)
+		}
+		if p, ok := sc["pattern"].(string); ok {
+			if rx, err := regexp.Compile(p); err != nil {
+				fail("invalid pattern %q: %v", p, err)
+			} else if !rx.MatchString(val) {
+				fail("%q does not match %q", val, p)
+			}
+		}
+	case json.Number:
+		f, _ := val.Float64()
+		if n, ok := schemaNumber(sc["minimum"]); ok && f < n {
+			fail("expected minimum %v, got %v", n, val)
+		}
+		if n, ok := schemaNumber(sc["maximum"]); ok && f > n {
+			fail("expected maximum %v, got %v", n, val)
+		}
+		if n, ok := schemaNumber(sc["exclusiveMinimum"]); ok && f <= n {
+			fail("expected more than %v, got %v", n, val)
+		}
+		if n, ok := schemaNumber(sc["exclusiveMaximum"]); ok && f >= n {
+			fail("expected less than %v, got %v", n, val)
+		}
+	}
+
+	for _, sub := range asSlice(sc["allOf"]) {
+		if m, ok := sub.(map[string]interface{}); ok {
+			errs = append(errs, s.check(m, v, path, refs)...)
+		}
+	}
+	if anyOf := asSlice(sc["anyOf"]); len(anyOf) != 0 && s.matching(anyOf, v, path, refs) == 0 {
+		fail("does not match any of anyOf")
+	}
+	if oneOf := asSlice(sc["oneOf"]); len(oneOf) != 0 {
+		if n := s.matching(oneOf, v, path, refs); n != 1 {
+			fail("expected to match exactly one of oneOf, matched %d", n)
+		}
+	}
+	if not, ok := sc["not"].(map[string]interface{}); ok && len(s.check(not, v, path, refs)) == 0 {
+		fail("must not match 'not' schema")
+	}
+	return errs
+}
+
+func (s schema) matching(schemas []interface{}, v interface{}, path string, refs map[string]bool) int {
+	n := 0
+	for _, sub := range schemas {
+		if m, ok := sub.(map[string]interface{}); ok && len(s.check(m, v, path, refs)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (s schema) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local $ref are supported, got '%s'", ref)
+	}
+	var current interface{} = s.root
+	for _, name := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if name == "" {
+			continue
+		}
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("$ref '%s' not found", ref)
+		}
+		if current, ok = m[name]; !ok {
+			return nil, fmt.Errorf("$ref '%s' not found", ref)
+		}
+	}
+	m, ok := current.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$ref '%s' is not a schema", ref)
+	}
+	return m, nil
+}
+
+func typeMatches(t interface{}, v interface{}) bool {
+	if list, ok := t.([]interface{}); ok {
+		for _, one := range list {
+			if typeMatches(one, v) {
+				return true
+			}
+		}
+		return false
+	}
+	switch t {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == float64(int64(f))
+	}
+	return true
+}
+
+func inValues(values []interface{}, v interface{}) bool {
+	for _, e := range values {
+		if reflect.DeepEqual(normalise(e), normalise(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalise numbers so 1 and 1.0 are equal
+func normalise(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		f, _ := val.Float64()
+		return f
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, e := range val {
+			out[i] = normalise(e)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			out[k] = normalise(e)
+		}
+		return out
+	}
+	return v
+}
+
+func schemaNumber(v interface{}) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+func compact(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
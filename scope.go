@@ -1,18 +1,38 @@
 package lash
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync"
 )
 
 type (
-	// Scope for lash
+	// Scope for lash, safe for concurrent use
 	Scope struct {
-		err            error
+		mu             sync.Mutex
+		errs           []error // the errors set at once, see Err
+		unsent         []error // errors for the parent of a child scope when it Ends
+		errCount       int     // errors since ClearError
+		firstErr       error
 		onErr          OnErrorFunc
-		stdout, stderr io.Writer
+		stopOnErr      bool    // see StopOnError
+		queued         []error // errors waiting for the OnError func
+		delivering     bool    // true while the OnError func is being called
+		stdout, stderr *lockedWriter
+		parent         *Scope
+		propagate      PropagateMode
+		ended          bool
+		items, failed  int
+		ctx            context.Context
+		cancel         context.CancelFunc
+		http           *httpState
+		httpOwned      bool // false until a child scope changes the http state of its parent, see ownHTTP
+		verbose        bool
+		dryRun         bool
 	}
 	// ScopeErr an error that occurred during a scope operation
 	ScopeErr struct {
@@ -20,6 +40,11 @@ type (
 		Action string
 		Err    error
 	}
+	// MultiErr is returned by Scope.Err when more than one error has occurred at once,
+	// e.g. when several go routines fail together
+	MultiErr struct {
+		Errs []error
+	}
 	// OnErrorFunc perform some action
 	OnErrorFunc func(error)
 )
@@ -27,11 +52,16 @@ type (
 // NewScope for lash, you can have as many as you want, they are separate
 // the default OnError handler is Terminate
 func NewScope() *Scope {
+	outMu := &sync.Mutex{}
 	s := Scope{
-		stdout: os.Stdout,
-		stderr: os.Stderr,
+		stdout:    &lockedWriter{mu: outMu, w: os.Stdout},
+		stderr:    &lockedWriter{mu: outMu, w: os.Stderr},
+		http:      &httpState{},
+		httpOwned: true,
 	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
 	s.onErr = s.Terminate
+	s.stopOnErr = true
 	return &s
 }
 
@@ -40,21 +70,49 @@ func (s *Scope) IsError() bool {
 	if s == nil {
 		return false
 	}
-	return s.err != nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.errs) != 0
 }
 
-// OnError do something
+// OnError do something, this turns off StopOnError so call that afterwards if it is still wanted
 func (s *Scope) OnError(fn OnErrorFunc) *Scope {
+	s.mu.Lock()
 	s.onErr = fn
+	s.stopOnErr = false
+	s.mu.Unlock()
+	return s
+}
+
+// StopOnError makes ForEach and ForEachOrdered start no more lines after a line fails, this is
+// the default for a new scope as the OnError func is Terminate
+func (s *Scope) StopOnError() *Scope {
+	s.mu.Lock()
+	s.stopOnErr = true
+	s.mu.Unlock()
 	return s
 }
 
-// Err is the raw scope error if any
+// maxErrs kept for Err and for the parent of a child scope, the oldest are dropped
+const maxErrs = 100
+
+// Err is the raw scope error if any, this is the last error unless several errors happened at once
+// (an error was set while the OnError func was handling another), then it is a *MultiErr of them
 func (s *Scope) Err() error {
-	return s.err
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch len(s.errs) {
+	case 0:
+		return nil
+	case 1:
+		return s.errs[0]
+	}
+	return &MultiErr{Errs: append([]error(nil), s.errs...)}
 }
 
-// SetErr is the raw scope error if any
+// SetErr is the raw scope error if any, the OnError func is called for each error,
+// never concurrently. Errors set while the OnError func is running (by other go routines
+// or by the OnError func itself) are queued and handled after it returns
 func (s *Scope) SetErr(err error) {
 	if s == nil || err == nil {
 		return
@@ -63,9 +121,56 @@ func (s *Scope) SetErr(err error) {
 	if ok && serr.Err == nil {
 		return
 	}
-	s.err = err
-	if s.onErr != nil {
-		s.onErr(err)
+	s.mu.Lock()
+	if !s.delivering {
+		// nothing else is being handled so this is the start of a new set
+		s.errs = nil
+	}
+	if len(s.errs) == maxErrs {
+		s.errs = s.errs[1:]
+	}
+	s.errs = append(s.errs, err)
+	if s.parent != nil {
+		if len(s.unsent) == maxErrs {
+			s.unsent = s.unsent[1:]
+		}
+		s.unsent = append(s.unsent, err)
+	}
+	if s.errCount == 0 {
+		s.firstErr = err
+	}
+	s.errCount++
+	s.queued = append(s.queued, err)
+	deliver := !s.delivering
+	s.delivering = true
+	each := s.parent != nil && s.propagate == PropagateEach
+	s.mu.Unlock()
+
+	if deliver {
+		s.deliver()
+	}
+	if each {
+		s.parent.SetErr(err)
+	}
+}
+
+// deliver the queued errors to the OnError func one at a time until the queue is empty
+func (s *Scope) deliver() {
+	for {
+		s.mu.Lock()
+		if len(s.queued) == 0 {
+			s.delivering = false
+			s.mu.Unlock()
+			return
+		}
+		err := s.queued[0]
+		s.queued = s.queued[1:]
+		onErr := s.onErr
+		s.mu.Unlock()
+
+		if onErr != nil {
+			onErr(err)
+		}
 	}
 }
 
@@ -78,7 +183,9 @@ func (s *Scope) setErr(t, action string, err error) {
 
 // ClearError removes the raw scope error if any
 func (s *Scope) ClearError() {
-	s.err = nil
+	s.mu.Lock()
+	s.errs, s.unsent, s.errCount, s.firstErr = nil, nil, 0, nil
+	s.mu.Unlock()
 }
 
 // AsJson turns a map or struct (or json-able) thing into json buffer
@@ -115,7 +222,7 @@ func (s *Scope) Args() RequireArg {
 
 // SetOutput for Println etc. defaults to os.Stdout
 func (s *Scope) SetOutput(writer io.Writer) *Scope {
-	s.stdout = writer
+	s.stdout.set(writer)
 	return s
 }
 
@@ -126,7 +233,7 @@ func (s *Scope) Println(msg string, args ...interface{}) {
 
 // SetErrOutput for Error writing defaults to os.Stderr
 func (s *Scope) SetErrOutput(writer io.Writer) *Scope {
-	s.stderr = writer
+	s.stderr.set(writer)
 	return s
 }
 
@@ -162,3 +269,36 @@ func (e *ScopeErr) fail(action string, err error) error {
 func (e *ScopeErr) Error() string {
 	return fmt.Sprintf("%s:%s:%v", e.Type, e.Action, e.Err)
 }
+
+// Error interface, one line per error
+func (e *MultiErr) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors:\n%s", len(e.Errs), strings.Join(msgs, "\n"))
+}
+
+// Unwrap the individual errors
+func (e *MultiErr) Unwrap() []error {
+	return e.Errs
+}
+
+// lockedWriter serialises writes, stdout and stderr of a scope share a lock
+// so lines from different go routines are not mixed up
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+func (w *lockedWriter) set(writer io.Writer) {
+	w.mu.Lock()
+	w.w = writer
+	w.mu.Unlock()
+}
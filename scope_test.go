@@ -1,7 +1,13 @@
 package lash_test
 
 import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/NearlyUnique/lash"
 	"github.com/stretchr/testify/assert"
@@ -23,6 +29,98 @@ func Test_simplify_making_json_data(t *testing.T) {
 	assert.Equal(t, `{"Text":"some text","Number":12.34}`, string(buf))
 }
 
+func Test_scope_can_be_shared_between_go_routines(t *testing.T) {
+	t.Run("errors from several go routines at once are aggregated", func(t *testing.T) {
+		release := make(chan struct{})
+		var handled []error
+		scope := lash.NewScope().OnError(func(err error) {
+			// never called concurrently so no lock required
+			<-release
+			handled = append(handled, err)
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				scope.SetErr(fmt.Errorf("error %d", i))
+				_ = scope.IsError()
+			}(i)
+		}
+		// the first error is still being handled when the rest are set
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			if multi, ok := scope.Err().(*lash.MultiErr); ok && len(multi.Errs) == 10 {
+				break
+			}
+		}
+		close(release)
+		wg.Wait()
+
+		assert.Len(t, handled, 10)
+		multi, ok := scope.Err().(*lash.MultiErr)
+		require.True(t, ok)
+		assert.Len(t, multi.Errs, 10)
+		assert.Contains(t, multi.Error(), "10 errors")
+
+		scope.ClearError()
+		assert.NoError(t, scope.Err())
+	})
+	t.Run("the error handler can set errors on its own scope", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {})
+		ts.Close()
+		var handled []error
+		var scope *lash.Scope
+		scope = lash.NewScope().OnError(func(err error) {
+			handled = append(handled, err)
+			if len(handled) == 1 {
+				// e.g. a notification that fails
+				scope.Curl(ts.URL).Response()
+			}
+		})
+
+		scope.SetErr(fmt.Errorf("first"))
+
+		require.Len(t, handled, 2)
+		assert.EqualError(t, handled[0], "first")
+		assert.Contains(t, handled[1].Error(), "HTTPRequest")
+	})
+	t.Run("a single error is returned as is", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore)
+		scope.SetErr(fmt.Errorf("only one"))
+
+		assert.EqualError(t, scope.Err(), "only one")
+	})
+	t.Run("errors one after another are not aggregated", func(t *testing.T) {
+		scope := lash.NewScope().OnError(lash.Ignore)
+		for i := 0; i < 1000; i++ {
+			scope.SetErr(fmt.Errorf("error %d", i))
+		}
+
+		assert.EqualError(t, scope.Err(), "error 999")
+	})
+	t.Run("output lines are not mixed up", func(t *testing.T) {
+		var buf bytes.Buffer
+		scope := lash.NewScope().SetOutput(&buf)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				scope.Println("line $0 from a go routine", i)
+			}(i)
+		}
+		wg.Wait()
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(t, lines, 10)
+		for _, line := range lines {
+			assert.Regexp(t, `^line \d from a go routine$`, line)
+		}
+	})
+}
+
 func requireNoError(t *testing.T) func(error) {
 	return func(err error) {
 		require.NoError(t, err)
@@ -0,0 +1,95 @@
+package lash
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type (
+	// Service is a base url with defaults for every request made with it
+	Service struct {
+		scope    *Scope
+		base     string
+		headers  http.Header
+		defaults []func(cmd *HTTPRequest)
+	}
+)
+
+// Service for requests relative to base, which supports EnvStr
+func (s *Scope) Service(base string, args ...interface{}) *Service {
+	return &Service{
+		scope:   s,
+		base:    strings.TrimSuffix(s.EnvStr(base, args...), "/"),
+		headers: http.Header{},
+	}
+}
+
+// Header for every request, this overwrites any previous value. The value supports EnvStr
+func (svc *Service) Header(name, value string, args ...interface{}) *Service {
+	svc.headers.Set(name, svc.scope.EnvStr(value, args...))
+	return svc
+}
+
+// AuthBasic for every request, both values support EnvStr with the same args
+func (svc *Service) AuthBasic(username, password string, args ...interface{}) *Service {
+	username, password = svc.scope.EnvStr(username, args...), svc.scope.EnvStr(password, args...)
+	return svc.CommonFunc(func(cmd *HTTPRequest) {
+		cmd.Req.SetBasicAuth(username, password)
+	})
+}
+
+// AuthBearer for every request, the token supports EnvStr
+func (svc *Service) AuthBearer(token string, args ...interface{}) *Service {
+	return svc.Header("Authorization", "Bearer "+svc.scope.EnvStr(token, args...))
+}
+
+// AuthClientCredentials for every request, see HTTPRequest.AuthClientCredentials
+func (svc *Service) AuthClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) *Service {
+	return svc.CommonFunc(func(cmd *HTTPRequest) {
+		cmd.AuthClientCredentials(tokenURL, clientID, clientSecret, scopes...)
+	})
+}
+
+// CommonFunc is called for every request after the headers have been set
+func (svc *Service) CommonFunc(custom func(r *HTTPRequest)) *Service {
+	if custom != nil {
+		svc.defaults = append(svc.defaults, custom)
+	}
+	return svc
+}
+
+// For a copy of the service that makes requests with s, e.g. a ForEach worker scope
+func (svc *Service) For(s *Scope) *Service {
+	c := *svc
+	c.scope = s
+	return &c
+}
+
+// Curl a path relative to the base url, the path supports EnvStr. The path is added to the
+// base path so "/users" with a base of "https://host/v1" is "https://host/v1/users",
+// an absolute url is used as is. The headers, auth and CommonFuncs of the service are only
+// used when the url has the scheme and host of the base url so credentials are not sent elsewhere
+func (svc *Service) Curl(path string, args ...interface{}) *HTTPRequest {
+	path = svc.scope.EnvStr(path, args...)
+	if u, err := url.Parse(path); err != nil || !u.IsAbs() {
+		path = svc.base + "/" + strings.TrimPrefix(path, "/")
+	}
+	cmd := svc.scope.newHTTPRequest("Curl", path)
+	if cmd.Req == nil || !svc.sameOrigin(cmd.Req.URL) {
+		return cmd
+	}
+	for name, values := range svc.headers {
+		cmd.Req.Header[name] = append([]string(nil), values...)
+	}
+	for _, fn := range svc.defaults {
+		fn(cmd)
+	}
+	return cmd
+}
+
+// sameOrigin as the base url
+func (svc *Service) sameOrigin(u *url.URL) bool {
+	base, err := url.Parse(svc.base)
+	return err == nil && strings.EqualFold(base.Scheme, u.Scheme) && strings.EqualFold(base.Host, u.Host)
+}
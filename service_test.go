@@ -0,0 +1,75 @@
+package lash_test
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_service(t *testing.T) {
+	t.Run("paths are relative to the base and defaults are applied", func(t *testing.T) {
+		var requests []*http.Request
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+		})
+		defer ts.Close()
+		require.NoError(t, os.Setenv("service_test_token", "abc"))
+		defer func() { _ = os.Unsetenv("service_test_token") }()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		api := scope.Service(ts.URL+"/v1/").
+			Header("X-Team", "x").
+			AuthBearer("$service_test_token").
+			CommonFunc(func(r *lash.HTTPRequest) { r.Query("page", "1") })
+		api.Curl("/users/$0", 42).Response()
+		api.Curl("items").Header("X-Team", "y").Response()
+
+		require.Len(t, requests, 2)
+		assert.Equal(t, "/v1/users/42", requests[0].URL.Path)
+		assert.Equal(t, "1", requests[0].URL.Query().Get("page"))
+		assert.Equal(t, "x", requests[0].Header.Get("X-Team"))
+		assert.Equal(t, "Bearer abc", requests[0].Header.Get("Authorization"))
+		assert.Equal(t, "/v1/items", requests[1].URL.Path)
+		assert.Equal(t, "y", requests[1].Header.Get("X-Team"))
+	})
+	t.Run("absolute urls are used as is", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		cmd := scope.Service("https://api.example.com").Curl("https://other.example.com/x")
+
+		assert.Equal(t, "https://other.example.com/x", cmd.Req.URL.String())
+	})
+	t.Run("credentials are only sent to the base host", func(t *testing.T) {
+		scope := lash.NewScope().OnError(requireNoError(t))
+		api := scope.Service("https://api.example.com/v1").Header("X-Team", "x").AuthBearer("secret")
+
+		same := api.Curl("https://API.example.com/v2/items")
+		other := api.Curl("https://other.example.com/x")
+		plain := api.Curl("http://api.example.com/x")
+
+		assert.Equal(t, "Bearer secret", same.Req.Header.Get("Authorization"))
+		assert.Equal(t, "x", same.Req.Header.Get("X-Team"))
+		assert.Empty(t, other.Req.Header.Get("Authorization"))
+		assert.Empty(t, other.Req.Header.Get("X-Team"))
+		assert.Empty(t, plain.Req.Header.Get("Authorization"))
+	})
+	t.Run("requests can be made with another scope", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+		api := scope.Service(ts.URL)
+
+		var actualErr error
+		child := scope.Child().OnError(func(err error) { actualErr = err }).Propagate(lash.PropagateNone)
+		api.For(child).Curl("/missing").Response()
+
+		require.Error(t, actualErr)
+		assert.False(t, scope.IsError())
+	})
+}
@@ -0,0 +1,192 @@
+package lash
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// ProgressFunc is called as a body is written, total is -1 when the length is unknown
+	ProgressFunc func(written, total int64)
+	// SSEEvent a Server-Sent Event
+	SSEEvent struct {
+		ID    string
+		Event string
+		Data  string
+		Retry int
+	}
+	progressWriter struct {
+		w        io.Writer
+		written  int64
+		total    int64
+		progress ProgressFunc
+	}
+)
+
+// longest line (or NDJSON record) that can be streamed
+const maxStreamLine = 16 * 1024 * 1024
+
+// SaveTo streams the response body into the file (replacing any content) without holding it in memory.
+// The response has no body but the status etc. are available
+func (cmd *HTTPRequest) SaveTo(f *File, progress ...ProgressFunc) *HTTPResponse {
+	return cmd.stream("SaveTo", func(ctx context.Context, r *HTTPResponse) error {
+		out, err := os.Create(f.path)
+		if err != nil {
+			return err
+		}
+		w := &progressWriter{w: out, total: r.response.ContentLength}
+		if len(progress) != 0 {
+			w.progress = progress[0]
+		}
+		_, err = io.Copy(w, r.response.Body)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+}
+
+// Lines of the response body via a channel one line at a time, see File.ReadLines
+func (cmd *HTTPRequest) Lines() chan string {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		cmd.stream("Lines", func(ctx context.Context, r *HTTPResponse) error {
+			scanner := newLineScanner(r.response.Body)
+			for scanner.Scan() {
+				select {
+				case ch <- scanner.Text():
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return scanner.Err()
+		})
+	}()
+	return ch
+}
+
+// NDJSON records of the response body via a channel one record at a time, blank lines are skipped,
+// an invalid record is an error and ends the stream
+func (cmd *HTTPRequest) NDJSON() chan json.RawMessage {
+	ch := make(chan json.RawMessage)
+	go func() {
+		defer close(ch)
+		cmd.stream("NDJSON", func(ctx context.Context, r *HTTPResponse) error {
+			scanner := newLineScanner(r.response.Body)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line == "" {
+					continue
+				}
+				var record json.RawMessage
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					return err
+				}
+				select {
+				case ch <- record:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return scanner.Err()
+		})
+	}()
+	return ch
+}
+
+// Events of a text/event-stream response via a channel one event at a time
+func (cmd *HTTPRequest) Events() chan SSEEvent {
+	ch := make(chan SSEEvent)
+	cmd.Req.Header.Set("Accept", "text/event-stream")
+	go func() {
+		defer close(ch)
+		cmd.stream("Events", func(ctx context.Context, r *HTTPResponse) error {
+			scanner := newLineScanner(r.response.Body)
+			var ev SSEEvent
+			var data []string
+			for scanner.Scan() {
+				line := scanner.Text()
+				if line != "" {
+					parseSSELine(line, &ev, &data)
+					continue
+				}
+				if len(data) != 0 {
+					ev.Data = strings.Join(data, "\n")
+					select {
+					case ch <- ev:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				// the id is kept between events as per the spec
+				ev = SSEEvent{ID: ev.ID}
+				data = nil
+			}
+			return scanner.Err()
+		})
+	}()
+	return ch
+}
+
+// stream sends the request and passes the unread body to fn, the body is closed afterwards
+func (cmd *HTTPRequest) stream(action string, fn func(ctx context.Context, r *HTTPResponse) error) *HTTPResponse {
+	r := &HTTPResponse{scope: cmd.scope}
+	ctx, cancel := cmd.context()
+	defer cancel()
+	start := time.Now()
+	defer func() { r.duration = time.Since(start) }()
+	var ok bool
+	r.response, ok = cmd.send(ctx)
+	if !ok {
+		return r
+	}
+	defer func() { _ = r.response.Body.Close() }()
+	if err := fn(ctx, r); err != nil {
+		cmd.scope.SetErr(cmd.serr.fail(action, err))
+	}
+	return r
+}
+
+func parseSSELine(line string, ev *SSEEvent, data *[]string) {
+	if strings.HasPrefix(line, ":") {
+		return
+	}
+	field, value := line, ""
+	if i := strings.Index(line, ":"); i >= 0 {
+		field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+	}
+	switch field {
+	case "data":
+		*data = append(*data, value)
+	case "event":
+		ev.Event = value
+	case "id":
+		ev.ID = value
+	case "retry":
+		if n, err := strconv.Atoi(value); err == nil {
+			ev.Retry = n
+		}
+	}
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamLine)
+	return scanner
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.written += int64(n)
+	if w.progress != nil {
+		w.progress(w.written, w.total)
+	}
+	return n, err
+}
@@ -0,0 +1,115 @@
+package lash_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_streaming_responses(t *testing.T) {
+	t.Run("body can be saved to a file with progress", func(t *testing.T) {
+		content := strings.Repeat("0123456789", 10000)
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "100000")
+			_, _ = w.Write([]byte(content))
+		})
+		defer ts.Close()
+		filename := tempPathname()
+		defer func() { _ = os.Remove(filename) }()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		var lastWritten, lastTotal int64
+		resp := scope.Curl(ts.URL).SaveTo(scope.OpenFile(filename), func(written, total int64) {
+			lastWritten, lastTotal = written, total
+		})
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+		assert.Equal(t, int64(100000), lastWritten)
+		assert.Equal(t, int64(100000), lastTotal)
+		actual, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+	})
+	t.Run("body can be read line by line", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("one\ntwo\nthree"))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		var lines []string
+		for line := range scope.Curl(ts.URL).Lines() {
+			lines = append(lines, line)
+		}
+
+		assert.Equal(t, []string{"one", "two", "three"}, lines)
+	})
+	t.Run("ndjson records one at a time", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("{\"id\":1}\n\n{\"id\":2}\n"))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		var ids []int
+		for record := range scope.Curl(ts.URL).NDJSON() {
+			var v struct{ ID int }
+			require.NoError(t, json.Unmarshal(record, &v))
+			ids = append(ids, v.ID)
+		}
+
+		assert.Equal(t, []int{1, 2}, ids)
+	})
+	t.Run("invalid ndjson is an error", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("{\"id\":1}\nnot json\n{\"id\":2}\n"))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		count := 0
+		for range scope.Curl(ts.URL).NDJSON() {
+			count++
+		}
+
+		assert.Equal(t, 1, count)
+		assert.Contains(t, scope.Err().Error(), "HTTPRequest:NDJSON")
+	})
+	t.Run("server sent events", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+			_, _ = w.Write([]byte(": comment\nid: 1\nevent: greeting\ndata: hello\ndata: world\n\ndata: second\nretry: 10\n\n"))
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(requireNoError(t))
+
+		var events []lash.SSEEvent
+		for ev := range scope.Curl(ts.URL).Events() {
+			events = append(events, ev)
+		}
+
+		assert.Equal(t, []lash.SSEEvent{
+			{ID: "1", Event: "greeting", Data: "hello\nworld"},
+			{ID: "1", Data: "second", Retry: 10},
+		}, events)
+	})
+	t.Run("status errors are reported and the channel is closed", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		defer ts.Close()
+		scope := lash.NewScope().OnError(lash.Ignore)
+
+		for range scope.Curl(ts.URL).Lines() {
+		}
+
+		assert.Contains(t, scope.Err().Error(), "status 404 not allowed")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"golang.org/x/xerrors"
 )
@@ -55,3 +56,106 @@ func replaceAllStringSubMatchFunc(re *regexp.Regexp, str string, repl func(args
 
 	return result + str[lastIndex:]
 }
+
+// argPart of a command line argument, a literal part was single quoted or escaped
+// so it must not be interpolated
+type argPart struct {
+	text    string
+	literal bool
+}
+
+// splitArgs breaks a command line into arguments in the way a shell would,
+// single quotes are literal, double quotes allow \ escapes and outside of
+// quotes \ escapes the next character (a \ at the end of a line is a continuation)
+func splitArgs(line string) ([]string, error) {
+	parts, err := splitArgParts(line)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(parts))
+	for i, arg := range parts {
+		for _, p := range arg {
+			args[i] += p.text
+		}
+	}
+	return args, nil
+}
+
+// splitArgParts as splitArgs, each argument is split where it changes between literal and not
+func splitArgParts(line string) ([][]argPart, error) {
+	var args [][]argPart
+	var current []rune
+	var literal []bool
+	inArg := false
+	var quote rune
+	escaped := false
+
+	add := func(r rune, lit bool) {
+		current = append(current, r)
+		literal = append(literal, lit)
+	}
+	end := func() {
+		var arg []argPart
+		for i, r := range current {
+			if i == 0 || literal[i] != literal[i-1] {
+				arg = append(arg, argPart{literal: literal[i]})
+			}
+			arg[len(arg)-1].text += string(r)
+		}
+		if arg == nil {
+			// an empty quoted argument
+			arg = []argPart{{}}
+		}
+		args = append(args, arg)
+		current, literal = current[:0], literal[:0]
+	}
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+			if quote == '"' && !strings.ContainsRune("\"\\$`\n", r) {
+				add('\\', false)
+			}
+			if r != '\n' {
+				add(r, true)
+				inArg = true
+			}
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				add(r, true)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				add(r, false)
+			}
+		case r == '\\':
+			escaped = true
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				end()
+				inArg = false
+			}
+		default:
+			add(r, false)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, xerrors.Errorf("unterminated %c quote in '%s'", quote, line)
+	}
+	if inArg {
+		end()
+	}
+	return args, nil
+}
@@ -0,0 +1,80 @@
+package lash
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// headers that are never logged
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization"}
+
+// Verbose writes each http request (method, url and headers) and response status to the error output,
+// Authorization headers are redacted
+func (s *Scope) Verbose() *Scope {
+	s.mu.Lock()
+	s.verbose = true
+	s.mu.Unlock()
+	return s
+}
+
+// DryRun writes the http requests, commands, file writes and deletes to the error output instead of doing them.
+// Http responses are empty with a status of zero, commands have no output and an exit code of zero
+func (s *Scope) DryRun() *Scope {
+	s.mu.Lock()
+	s.dryRun = true
+	s.mu.Unlock()
+	return s
+}
+
+// IsDryRun for this scope
+func (s *Scope) IsDryRun() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dryRun
+}
+
+func (s *Scope) isVerbose() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.verbose
+}
+
+// dryRunf reports what would have happened, true if this is a dry run
+func (s *Scope) dryRunf(format string, args ...interface{}) bool {
+	if !s.IsDryRun() {
+		return false
+	}
+	_, _ = fmt.Fprintf(s.stderr, "[dry-run] "+format+"\n", args...)
+	return true
+}
+
+// logRequest as a single write so concurrent requests are not mixed up
+func (s *Scope) logRequest(prefix string, req *http.Request) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s %s\n", prefix, req.Method, req.URL)
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range req.Header[name] {
+			if isRedacted(name) {
+				v = "[redacted]"
+			}
+			fmt.Fprintf(&b, "%s%s: %s\n", prefix, name, v)
+		}
+	}
+	_, _ = fmt.Fprint(s.stderr, b.String())
+}
+
+func isRedacted(name string) bool {
+	for _, r := range redactedHeaders {
+		if strings.EqualFold(r, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package lash_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_verbose_and_dry_run(t *testing.T) {
+	t.Run("verbose logs the request and status with authorization redacted", func(t *testing.T) {
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {})
+		defer ts.Close()
+		var stderr bytes.Buffer
+		scope := lash.NewScope().OnError(requireNoError(t)).Verbose()
+		scope.SetErrOutput(&stderr)
+
+		scope.Curl(ts.URL+"/any").AuthBearer("secret-token").Header("X-Trace", "abc").Response()
+
+		log := stderr.String()
+		assert.Contains(t, log, "> GET "+ts.URL+"/any\n")
+		assert.Contains(t, log, "> Authorization: [redacted]\n")
+		assert.Contains(t, log, "> X-Trace: abc\n")
+		assert.Contains(t, log, "< 200 OK")
+		assert.NotContains(t, log, "secret-token")
+	})
+	t.Run("dry run does not send requests", func(t *testing.T) {
+		var calls int32
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+		})
+		defer ts.Close()
+		var stderr bytes.Buffer
+		scope := lash.NewScope().OnError(requireNoError(t)).DryRun()
+		scope.SetErrOutput(&stderr)
+
+		resp := scope.Curl(ts.URL).Delete().AuthBasic("user", "pass").Response()
+
+		assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+		assert.Equal(t, 0, resp.StatusCode())
+		assert.Contains(t, stderr.String(), "[dry-run] DELETE "+ts.URL+"\n")
+		assert.Contains(t, stderr.String(), "[dry-run] Authorization: [redacted]\n")
+	})
+	t.Run("dry run does not change files", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, "original\n")()
+		var stderr bytes.Buffer
+		scope := lash.NewScope().OnError(requireNoError(t)).DryRun()
+		scope.SetErrOutput(&stderr)
+
+		f := scope.OpenFile(filename)
+		f.AppendLine("more $0", "text")
+		f.Close()
+		f.Delete()
+
+		actual, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, "original\n", string(actual))
+		assert.Contains(t, stderr.String(), "[dry-run] append to '"+filename+"': more text\n")
+		assert.Contains(t, stderr.String(), "[dry-run] delete '"+filename+"'\n")
+	})
+	t.Run("dry run does not record fixtures", func(t *testing.T) {
+		filename := tempPathname()
+		defer writeFile(t, filename, "recorded\n")()
+		var stderr bytes.Buffer
+		scope := lash.NewScope().OnError(requireNoError(t)).DryRun()
+		scope.SetErrOutput(&stderr)
+
+		scope.Record(scope.OpenFile(filename))
+
+		actual, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, "recorded\n", string(actual))
+		assert.Contains(t, stderr.String(), "[dry-run] record to '"+filename+"'\n")
+	})
+	t.Run("dry run does not run commands", func(t *testing.T) {
+		filename := tempPathname()
+		var stderr bytes.Buffer
+		scope := lash.NewScope().OnError(requireNoError(t)).DryRun()
+		scope.SetErrOutput(&stderr)
+
+		code := scope.Exec("touch $0", filename).Run()
+		output := scope.Exec("echo hello").Output()
+		scope.Pipeline("echo hello", "tee "+filename).Run()
+		var lines []string
+		for line := range scope.Pipeline("echo hello", "sort").Lines() {
+			lines = append(lines, line)
+		}
+		scope.Pipeline("echo hello").StdinLines(numberedLines(1000)).ToFile(scope.OpenFile(filename))
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "", output)
+		assert.Empty(t, lines)
+		assertFileNotExists(t, filename)
+		log := stderr.String()
+		assert.Contains(t, log, "[dry-run] run 'touch "+filename+"'\n")
+		assert.Contains(t, log, "[dry-run] run 'echo hello'\n")
+		assert.Contains(t, log, "[dry-run] run 'echo hello | tee "+filename+"'\n")
+		assert.Contains(t, log, "[dry-run] write output to '"+filename+"'\n")
+	})
+	t.Run("dry run does not fetch oauth tokens", func(t *testing.T) {
+		var calls int32
+		ts := makeTestServer(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+		})
+		defer ts.Close()
+		var stderr bytes.Buffer
+		scope := lash.NewScope().OnError(requireNoError(t)).DryRun()
+		scope.SetErrOutput(&stderr)
+
+		req := scope.Curl(ts.URL).AuthClientCredentials(ts.URL+"/token", "id", "secret")
+		req.Response()
+
+		assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+		assert.Equal(t, "Bearer dry-run", req.Req.Header.Get("Authorization"))
+		assert.Contains(t, stderr.String(), "[dry-run] fetch token from '"+ts.URL+"/token'\n")
+	})
+	t.Run("children inherit dry run", func(t *testing.T) {
+		scope := lash.NewScope().DryRun()
+
+		assert.True(t, scope.Child().IsDryRun())
+	})
+}
@@ -0,0 +1,121 @@
+package lash
+
+import (
+	"bytes"
+	"sync"
+)
+
+type (
+	// WorkerFunc processes one line on its own scope
+	WorkerFunc func(s *Scope, line string)
+
+	workItem struct {
+		index          int
+		line           string
+		scope          *Scope
+		stdout, stderr *bytes.Buffer
+	}
+)
+
+// ForEach line from ch call fn on one of n worker go routines, each call gets its own scope
+// that writes to the outputs of this scope, output from different lines may be interleaved.
+// Each worker scope is a Child of this scope so errors are passed up as each line ends and
+// every line counts as an item for PropagateSummary. With StopOnError (the default until OnError is
+// called) no more lines are started after an error, the workers in progress finish and then only the
+// first error is passed to this scope, so with Terminate it terminates
+func (s *Scope) ForEach(ch <-chan string, workers int, fn WorkerFunc) {
+	s.forEach(ch, workers, false, fn)
+}
+
+// ForEachOrdered as ForEach but the output for each line is written in the order the lines
+// were received, output is buffered until all earlier lines have finished
+func (s *Scope) ForEachOrdered(ch <-chan string, workers int, fn WorkerFunc) {
+	s.forEach(ch, workers, true, fn)
+}
+
+func (s *Scope) forEach(ch <-chan string, workers int, ordered bool, fn WorkerFunc) {
+	if workers < 1 {
+		workers = 1
+	}
+	s.mu.Lock()
+	stopOnErr := s.stopOnErr
+	s.mu.Unlock()
+	ctx := s.Context()
+
+	work := make(chan *workItem)
+	results := make(chan *workItem)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				item.run(s, fn, ordered)
+				results <- item
+			}
+		}()
+	}
+
+	go func() {
+		defer close(work)
+		index := 0
+		for line := range ch {
+			select {
+			case work <- &workItem{index: index, line: line}:
+				index++
+			case <-stop:
+				// let the sender finish rather than block forever
+				for range ch {
+				}
+				return
+			case <-ctx.Done():
+				s.setErr("Scope", "ForEach", ctx.Err())
+				for range ch {
+				}
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// results are collected here so output and errors are never concurrent
+	var firstErr error
+	pending := map[int]*workItem{}
+	next := 0
+	for item := range results {
+		if ordered {
+			pending[item.index] = item
+			for pending[next] != nil {
+				_, _ = pending[next].stdout.WriteTo(s.stdout)
+				_, _ = pending[next].stderr.WriteTo(s.stderr)
+				delete(pending, next)
+				next++
+			}
+		}
+		if stopOnErr && item.scope.IsError() {
+			if firstErr == nil {
+				firstErr = item.scope.Err()
+				close(stop)
+			}
+			item.scope.Propagate(PropagateNone)
+		}
+		item.scope.End()
+	}
+	s.SetErr(firstErr)
+}
+
+func (item *workItem) run(parent *Scope, fn WorkerFunc, ordered bool) {
+	item.scope = parent.Child()
+	if ordered {
+		item.stdout, item.stderr = &bytes.Buffer{}, &bytes.Buffer{}
+		outMu := &sync.Mutex{}
+		item.scope.stdout = &lockedWriter{mu: outMu, w: item.stdout}
+		item.scope.stderr = &lockedWriter{mu: outMu, w: item.stderr}
+	}
+	fn(item.scope, item.line)
+}
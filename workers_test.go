@@ -0,0 +1,113 @@
+package lash_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NearlyUnique/lash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func numberedLines(n int) chan string {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		for i := 0; i < n; i++ {
+			ch <- fmt.Sprintf("line %d", i)
+		}
+	}()
+	return ch
+}
+
+func Test_for_each_line_in_parallel(t *testing.T) {
+	t.Run("every line is processed and output goes to the parent", func(t *testing.T) {
+		var buf bytes.Buffer
+		scope := lash.NewScope().OnError(requireNoError(t)).SetOutput(&buf)
+		var count int32
+
+		scope.ForEach(numberedLines(100), 8, func(s *lash.Scope, line string) {
+			atomic.AddInt32(&count, 1)
+			s.Println(line)
+		})
+
+		assert.Equal(t, int32(100), count)
+		assert.Equal(t, 100, strings.Count(buf.String(), "\n"))
+	})
+	t.Run("ordered output is in the same order as the lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		scope := lash.NewScope().OnError(requireNoError(t)).SetOutput(&buf)
+
+		scope.ForEachOrdered(numberedLines(20), 4, func(s *lash.Scope, line string) {
+			time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+			s.Println("$0 a", line)
+			s.Println("$0 b", line)
+		})
+
+		var expected []string
+		for i := 0; i < 20; i++ {
+			expected = append(expected, fmt.Sprintf("line %d a", i), fmt.Sprintf("line %d b", i))
+		}
+		assert.Equal(t, strings.Join(expected, "\n")+"\n", buf.String())
+	})
+	t.Run("errors from workers are passed to the parent scope", func(t *testing.T) {
+		var errs []error
+		scope := lash.NewScope().OnError(func(err error) { errs = append(errs, err) })
+
+		scope.ForEach(numberedLines(10), 3, func(s *lash.Scope, line string) {
+			if strings.HasSuffix(line, "3") || strings.HasSuffix(line, "7") {
+				s.SetErr(fmt.Errorf("failed %s", line))
+			}
+		})
+
+		require.Len(t, errs, 2)
+		assert.Error(t, scope.Err())
+	})
+	t.Run("with stop on error no more lines are started after an error", func(t *testing.T) {
+		var errs []error
+		var processed int32
+		scope := lash.NewScope().OnError(func(err error) { errs = append(errs, err) }).StopOnError()
+
+		scope.ForEach(numberedLines(1000), 2, func(s *lash.Scope, line string) {
+			atomic.AddInt32(&processed, 1)
+			if line == "line 5" {
+				s.SetErr(fmt.Errorf("stop at %s", line))
+			}
+			time.Sleep(time.Millisecond)
+		})
+
+		require.Len(t, errs, 1)
+		assert.EqualError(t, errs[0], "stop at line 5")
+		assert.True(t, atomic.LoadInt32(&processed) < 20)
+	})
+	t.Run("when the policy is terminate no more lines are started after an error", func(t *testing.T) {
+		if os.Getenv("LASH_TERMINATE_TEST") == "1" {
+			scope := lash.NewScope()
+			scope.SetErrOutput(os.Stdout)
+			scope.ForEach(numberedLines(1000), 2, func(s *lash.Scope, line string) {
+				s.Println("processed")
+				if line == "line 5" {
+					s.SetErr(fmt.Errorf("stop at %s", line))
+				}
+				time.Sleep(time.Millisecond)
+			})
+			return
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=Test_for_each_line_in_parallel/when_the_policy_is_terminate")
+		cmd.Env = append(os.Environ(), "LASH_TERMINATE_TEST=1")
+		out, err := cmd.Output()
+
+		exitErr, ok := err.(*exec.ExitError)
+		require.True(t, ok, "expected exit error, got %v", err)
+		assert.Equal(t, 1, exitErr.ExitCode())
+		assert.Contains(t, string(out), "stop at line 5")
+		assert.True(t, strings.Count(string(out), "processed") < 20)
+	})
+}